@@ -0,0 +1,223 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/vividboarder/docset-sfdc/classifier"
+	"github.com/vividboarder/docset-sfdc/logging"
+	"github.com/vividboarder/docset-sfdc/registry"
+)
+
+// defaultConfigPath is loaded if it exists and no other config is given
+const defaultConfigPath = "sfdashc.json"
+
+// configPath is the project configuration file to load
+var configPath = defaultConfigPath
+
+// Config describes a project's docset build in a single file
+// Flags given on the command line take precedence over values in the config
+// Relative paths are relative to the directory of the config file
+type Config struct {
+	// Deliverables to build or package when none are passed as args
+	Deliverables []string `json:"deliverables"`
+	Locales      []string `json:"locales"`
+	Version      string   `json:"version"`
+	AllVersions  *bool    `json:"all_versions"`
+	VersionRange string   `json:"version_range"`
+	BuildDir     string   `json:"build_dir"`
+	OutDir       string   `json:"out_dir"`
+	// Number of pages downloaded at once
+	Concurrency int `json:"concurrency"`
+	// Formats to package. Eg. docset and combined
+	Formats        []string `json:"formats"`
+	ResourcesDir   string   `json:"resources_dir"`
+	CSS            string   `json:"css"`
+	Dark           *bool    `json:"dark"`
+	Highlight      *bool    `json:"highlight"`
+	Sanitize       *bool    `json:"sanitize"`
+	SanitizePolicy string   `json:"sanitize_policy"`
+	PlatformFamily string   `json:"platform_family"`
+	FallbackURL    string   `json:"fallback_url"`
+	IndexPage      string   `json:"index_page"`
+	JavaScript     *bool    `json:"javascript"`
+	FTS            *bool    `json:"fts"`
+	KeepVersions   *bool    `json:"keep_versions"`
+	ArchiveHistory string   `json:"archive_history"`
+	Debug          *bool    `json:"debug"`
+	// Settings for each deliverable, replacing those in the registry
+	DeliverableSettings map[string]DeliverableConfig `json:"deliverable_settings"`
+}
+
+// DeliverableConfig overrides the registered settings of a deliverable
+type DeliverableConfig struct {
+	// Name used for the docset. Eg. Apex
+	FriendlyName string   `json:"friendly_name"`
+	Icon         string   `json:"icon"`
+	Aliases      []string `json:"aliases"`
+	// Path to a JSON file with a list of SupportedType rules
+	Rules          string `json:"rules"`
+	PlatformFamily string `json:"platform_family"`
+}
+
+// loadConfig reads a config file
+func loadConfig(path string) (config Config, err error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal(contents, &config)
+	if err != nil {
+		err = NewFormatedError("Could not read config %s: %s", path, err.Error())
+	}
+	return
+}
+
+// loadRules reads a list of SupportedType rules from a JSON file
+func loadRules(path string) (rules []classifier.SupportedType, err error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal(contents, &rules)
+	if err != nil {
+		err = NewFormatedError("Could not read rules %s: %s", path, err.Error())
+	}
+	return
+}
+
+// resolveConfigPath returns a path from the config relative to the directory of the config file
+func resolveConfigPath(path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(filepath.Dir(configPath), path)
+}
+
+// isFlagSet returns true if a flag was passed on the command line
+func isFlagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		set = set || f.Name == name
+	})
+	return set
+}
+
+// setFlagDefault sets a flag from the config unless it was passed on the command line
+func setFlagDefault(name string, value string) error {
+	if value == "" || isFlagSet(name) {
+		return nil
+	}
+	return flag.Set(name, value)
+}
+
+// setBoolFlagDefault sets a bool flag from the config unless it was passed on the command line
+func setBoolFlagDefault(name string, value *bool) error {
+	if value == nil {
+		return nil
+	}
+	return setFlagDefault(name, strconv.FormatBool(*value))
+}
+
+// applyConfig sets every flag that was not passed on the command line from the config
+func applyConfig(config Config) error {
+	var concurrency string
+	if config.Concurrency > 0 {
+		concurrency = strconv.Itoa(config.Concurrency)
+	}
+
+	for name, value := range map[string]string{
+		"locale":          strings.Join(config.Locales, ","),
+		"version":         config.Version,
+		"version-range":   config.VersionRange,
+		"build-dir":       resolveConfigPath(config.BuildDir),
+		"out-dir":         resolveConfigPath(config.OutDir),
+		"concurrency":     concurrency,
+		"formats":         strings.Join(config.Formats, ","),
+		"resources-dir":   resolveConfigPath(config.ResourcesDir),
+		"css":             resolveConfigPath(config.CSS),
+		"sanitize-policy": resolveConfigPath(config.SanitizePolicy),
+		"platform-family": config.PlatformFamily,
+		"fallback-url":    config.FallbackURL,
+		"index-page":      config.IndexPage,
+		"archive-history": resolveConfigPath(config.ArchiveHistory),
+	} {
+		if err := setFlagDefault(name, value); err != nil {
+			return err
+		}
+	}
+
+	for name, value := range map[string]*bool{
+		"all-versions":  config.AllVersions,
+		"dark":          config.Dark,
+		"highlight":     config.Highlight,
+		"sanitize":      config.Sanitize,
+		"javascript":    config.JavaScript,
+		"fts":           config.FTS,
+		"keep-versions": config.KeepVersions,
+		"debug":         config.Debug,
+	} {
+		if err := setBoolFlagDefault(name, value); err != nil {
+			return err
+		}
+	}
+
+	for name, settings := range config.DeliverableSettings {
+		err := applyDeliverableConfig(name, settings)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyDeliverableConfig replaces registered settings of a deliverable with those in the config
+// Only registered deliverables can be configured, since registering one changes it's name and icon
+func applyDeliverableConfig(name string, settings DeliverableConfig) error {
+	deliverable, ok := registry.Deliverables[name]
+	if !ok {
+		return NewFormatedError("Could not apply deliverable_settings: %s is not a registered deliverable", name)
+	}
+	if settings.FriendlyName != "" {
+		deliverable.FriendlyName = settings.FriendlyName
+	}
+	if settings.Icon != "" {
+		deliverable.Icon = settings.Icon
+	}
+	if settings.Aliases != nil {
+		deliverable.Aliases = settings.Aliases
+	}
+	if settings.PlatformFamily != "" {
+		deliverable.Plist.PlatformFamily = settings.PlatformFamily
+	}
+	if settings.Rules != "" {
+		rules, err := loadRules(resolveConfigPath(settings.Rules))
+		if err != nil {
+			return err
+		}
+		deliverable.Types = rules
+	}
+	registry.Deliverables[name] = deliverable
+	return nil
+}
+
+// readConfig loads the config file, if there is one, and applies it to any unset flags
+// The default config is optional, but one passed with -config must exist
+func readConfig() (config Config, err error) {
+	if _, statErr := os.Stat(configPath); os.IsNotExist(statErr) && configPath == defaultConfigPath {
+		return
+	}
+
+	config, err = loadConfig(configPath)
+	if err != nil {
+		return
+	}
+	logging.Info("Using config %s", configPath)
+	err = applyConfig(config)
+	return
+}
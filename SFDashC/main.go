@@ -1,322 +1,389 @@
 package main
 
 import (
-	"encoding/json"
 	"flag"
-	"fmt"
-	"io"
-	"io/ioutil"
-	"net/http"
 	"os"
-	"path/filepath"
-	"sync"
+	"strings"
+	"time"
+
+	"github.com/vividboarder/docset-sfdc/atlas"
+	"github.com/vividboarder/docset-sfdc/builder"
+	"github.com/vividboarder/docset-sfdc/logging"
+	"github.com/vividboarder/docset-sfdc/packager"
+	"github.com/vividboarder/docset-sfdc/renderer"
 )
 
-// CSS Paths
-var cssBaseURL = "https://developer.salesforce.com/resource/stylesheets"
-var cssFiles = []string{"holygrail.min.css", "docs.min.css", "syntax-highlighter.min.css"}
+// buildDir is where everything is downloaded, rendered and indexed before packaging
 var buildDir = "build"
 
-var wg sync.WaitGroup
-var throttle = make(chan int, maxConcurrency)
+// outDir is where docsets, archives and reports are written
+var outDir = "."
+
+var resourcesDir = "resources"
+
+// maxConcurrency is the number of pages downloaded at once
+var maxConcurrency = builder.DefaultConcurrency
+
+// showProgress displays the progress of downloads while building
+var showProgress = true
+
+// progressLogInterval is how often progress is logged when not writing to a terminal
+const progressLogInterval = 10 * time.Second
+
+// progressDrawInterval is how often the progress bar is redrawn on a terminal
+const progressDrawInterval = 250 * time.Millisecond
+
+// Render settings
+var customCSSPath string
+var darkTheme = true
+var highlightCode = true
+var sanitizeContent = true
+
+// sanitizePolicyPath is a JSON file containing a SanitizePolicy to use instead of the default
+var sanitizePolicyPath string
+
+// Package settings
+var packageFormats = []string{packager.DocsetFormat}
+var combineDocsets bool
+var plistOverrides packager.PlistOverrides
+
+// enableJavaScript and fullTextSearch only override the deliverable's plist settings when set
+var enableJavaScript bool
+var fullTextSearch bool
+var keepVersions = true
+var archiveHistoryDir string
+
+// Commands that can be passed before the deliverables
+// Each runs a single stage over the build dir so it can be re-run on it's own
+const fetchCommand = "fetch"
+const renderCommand = "render"
+const indexCommand = "index"
+const packageCommand = "package"
+const archiveCommand = "archive"
+const publishCommand = "publish"
+const reportCommand = "report"
+const diffCommand = "diff"
+
+var commands = []string{
+	fetchCommand,
+	renderCommand,
+	indexCommand,
+	packageCommand,
+	archiveCommand,
+	publishCommand,
+	reportCommand,
+	diffCommand,
+}
 
-const maxConcurrency = 16
+// configDeliverableCommands build or package deliverables, so they use those in the config if none are passed
+// An empty command runs every stage up to indexing
+var configDeliverableCommands = []string{
+	"",
+	fetchCommand,
+	renderCommand,
+	indexCommand,
+	packageCommand,
+	archiveCommand,
+	publishCommand,
+	reportCommand,
+}
 
-func parseFlags() (locale string, deliverables []string, debug bool) {
+func parseFlags() (command string, locales []string, version string, allVersions bool, versionRange string, deliverables []string, debug bool) {
+	var locale string
+	var formats string
+	flag.StringVar(
+		&configPath, "config", defaultConfigPath,
+		"path to a JSON config file with defaults for any flags that are not passed",
+	)
+	flag.StringVar(
+		&buildDir, "build-dir", buildDir,
+		"directory to download, render and index documentation into",
+	)
+	flag.StringVar(
+		&outDir, "out-dir", outDir,
+		"directory to write docsets, archives and reports to",
+	)
+	flag.IntVar(
+		&maxConcurrency, "concurrency", maxConcurrency,
+		"number of pages to download at once",
+	)
+	flag.BoolVar(
+		&showProgress, "progress", showProgress,
+		"show download progress. A bar on a terminal, otherwise a log line every 10 seconds",
+	)
+	flag.StringVar(
+		&formats, "formats", packager.DocsetFormat,
+		"comma separated formats to package: docset or combined",
+	)
+	flag.StringVar(
+		&resourcesDir, "resources-dir", resourcesDir,
+		"directory containing icons used for packaging and archiving",
+	)
+	flag.StringVar(
+		&locale, "locale", atlas.DefaultLocale,
+		"comma separated locales to use for documentation (default: en-us)",
+	)
 	flag.StringVar(
-		&locale, "locale", "en-us",
-		"locale to use for documentation (default: en-us)",
+		&version, "version", "",
+		"version to use for documentation by doc version, release or \"latest\" (default: current version)",
 	)
 	flag.BoolVar(
 		&debug, "debug", false, "this flag supresses warning messages",
 	)
+	flag.BoolVar(
+		&allVersions, "all-versions", false,
+		"build and package every available version into it's own build directory",
+	)
+	flag.StringVar(
+		&versionRange, "version-range", "",
+		"limit -all-versions to a range of doc versions formatted as min:max. Either can be omitted",
+	)
+	flag.StringVar(
+		&customCSSPath, "css", "",
+		"path to a custom stylesheet to include in every page",
+	)
+	flag.BoolVar(
+		&darkTheme, "dark", true,
+		"include a dark theme that is used when the viewer prefers a dark color scheme",
+	)
+	flag.BoolVar(
+		&sanitizeContent, "sanitize", true,
+		"remove scripts, trackers and widgets from downloaded pages",
+	)
+	flag.StringVar(
+		&plistOverrides.PlatformFamily, "platform-family", "",
+		"docset platform family used as a search keyword (default: based on deliverable)",
+	)
+	flag.StringVar(
+		&plistOverrides.FallbackURL, "fallback-url", "",
+		"url to open for pages that are not in the docset (default: "+packager.DefaultFallbackURL+")",
+	)
+	flag.StringVar(
+		&plistOverrides.IndexPage, "index-page", "",
+		"page to open when the docset is selected (default: <deliverable>.html)",
+	)
+	flag.BoolVar(
+		&enableJavaScript, "javascript", false,
+		"allow JavaScript to run in docset pages (default: based on deliverable)",
+	)
+	flag.BoolVar(
+		&fullTextSearch, "fts", false,
+		"enable full text search for the docset by default (default: based on deliverable)",
+	)
+	flag.BoolVar(
+		&keepVersions, "keep-versions", true,
+		"keep a copy of each archive in a versions directory to list in specific_versions",
+	)
+	flag.StringVar(
+		&archiveHistoryDir, "archive-history", "",
+		"directory of previously published docsets whose versions are added to specific_versions",
+	)
+	flag.BoolVar(
+		&highlightCode, "highlight", true,
+		"render syntax highlighting for code samples",
+	)
+	flag.BoolVar(
+		&combineDocsets, "combine", false,
+		"package all deliverables into a single Salesforce Platform docset. Same as -formats combined",
+	)
+	flag.StringVar(
+		&sanitizePolicyPath, "sanitize-policy", "",
+		"path to a JSON file with allowed tags and attributes for sanitizing",
+	)
 	flag.Parse()
 
 	// All other args are for deliverables
 	// apexcode, pages, or lightening
 	deliverables = flag.Args()
-	return
-}
 
-// getTOC Retrieves the TOC JSON and Unmarshals it
-func getTOC(locale string, deliverable string) (toc *AtlasTOC, err error) {
-	var tocURL = fmt.Sprintf("https://developer.salesforce.com/docs/get_document/atlas.%s.%s.meta", locale, deliverable)
-	LogDebug("TOC URL: %s", tocURL)
-	resp, err := http.Get(tocURL)
-	ExitIfError(err)
+	// An optional command can come before the deliverables
+	// Flags may also follow the command
+	if len(deliverables) > 0 && contains(commands, deliverables[0]) {
+		command = deliverables[0]
+		ExitIfError(flag.CommandLine.Parse(deliverables[1:]))
+		deliverables = flag.Args()
+	}
 
-	// Read the downloaded JSON
-	defer func() {
-		ExitIfError(resp.Body.Close())
-	}()
-	contents, err := ioutil.ReadAll(resp.Body)
+	config, err := readConfig()
 	ExitIfError(err)
-
-	// Load into Struct
-	toc = new(AtlasTOC)
-	LogDebug("TOC JSON: %s", string(contents))
-	err = json.Unmarshal([]byte(contents), toc)
-	return
-}
-
-// verifyVersion ensures that the version retrieved is the latest
-func verifyVersion(toc *AtlasTOC) error {
-	// jsonVersion, _ := json.Marshal(toc.Version)
-	// LogDebug("toc.Version" + string(jsonVersion))
-	currentVersion := toc.Version.DocVersion
-	// jsonAvailVersions, _ := json.Marshal(toc.AvailableVersions)
-	// LogDebug("toc.AvailableVersions" + string(jsonAvailVersions))
-	topVersion := toc.AvailableVersions[0].DocVersion
-	if currentVersion != topVersion {
-		return NewFormatedError("verifyVersion: retrieved version is not the latest. Found %s, latest is %s", currentVersion, topVersion)
+	// Other commands, like diff, take args that aren't deliverables
+	if len(deliverables) == 0 && contains(configDeliverableCommands, command) {
+		deliverables = config.Deliverables
 	}
-	return nil
-}
 
-func printSuccess(toc *AtlasTOC) {
-	LogInfo("Success: %s - %s - %s", toc.DocTitle, toc.Version.VersionText, toc.Version.DocVersion)
-}
-
-func saveMainContent(toc *AtlasTOC) {
-	filePath := fmt.Sprintf("%s.html", toc.Deliverable)
-	// Prepend build dir
-	filePath = filepath.Join(buildDir, filePath)
-	// Make sure file doesn't exist first
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		content := toc.Content
-
-		err = os.MkdirAll(filepath.Dir(filePath), 0755)
-		ExitIfError(err)
-
-		// TODO: Do something to format full page here
-
-		ofile, err := os.Create(filePath)
-		ExitIfError(err)
-
-		defer func() {
-			ExitIfError(ofile.Close())
-		}()
-		_, err = ofile.WriteString(
-			"<meta http-equiv='Content-Type' content='text/html; charset=UTF-8' />" +
-				content,
-		)
-		ExitIfError(err)
+	locales = strings.Split(locale, ",")
+	packageFormats = strings.Split(formats, ",")
+	if combineDocsets {
+		packageFormats = []string{packager.CombinedFormat}
 	}
+	return
 }
 
-// saveContentVersion will retrieve the version number from the TOC and save that to a text file
-func saveContentVersion(toc *AtlasTOC) {
-	filePath := fmt.Sprintf("%s-version.txt", toc.Deliverable)
-	// Prepend build dir
-	filePath = filepath.Join(buildDir, filePath)
-	err := os.MkdirAll(filepath.Dir(filePath), 0755)
-	ExitIfError(err)
-
-	ofile, err := os.Create(filePath)
-	ExitIfError(err)
-
-	defer func() {
-		ExitIfError(ofile.Close())
-	}()
-	_, err = ofile.WriteString(toc.Version.DocVersion)
-	ExitIfError(err)
-}
-
-// downloadCSS will download a CSS file using the CSS base URL
-func downloadCSS(fileName string, wg *sync.WaitGroup) {
-	downloadFile(cssBaseURL+"/"+fileName, fileName, wg)
+// newRenderer returns a Renderer with the render settings from the flags
+func newRenderer() (*renderer.Renderer, error) {
+	pageRenderer := renderer.New()
+	pageRenderer.Sanitize = sanitizeContent
+	pageRenderer.Highlight = highlightCode
+	pageRenderer.DarkTheme = darkTheme
+	pageRenderer.CustomCSSPath = customCSSPath
+	if sanitizePolicyPath != "" {
+		policy, err := renderer.LoadSanitizePolicy(sanitizePolicyPath)
+		if err != nil {
+			return nil, err
+		}
+		pageRenderer.Policy = policy
+	}
+	return pageRenderer, nil
 }
 
-// downloadFile will download n aribtrary file to a given file path
-// It will also handle throttling if a WaitGroup is provided
-func downloadFile(url string, fileName string, wg *sync.WaitGroup) {
-	if wg != nil {
-		defer wg.Done()
+// newPackager returns a Packager with the package settings from the flags
+func newPackager(pageRenderer *renderer.Renderer) *packager.Packager {
+	docsetPackager := packager.New(buildDir, outDir)
+	docsetPackager.ResourcesDir = resourcesDir
+	docsetPackager.Formats = packageFormats
+	docsetPackager.PlistOverrides = plistOverrides
+	if isFlagSet("javascript") {
+		docsetPackager.PlistOverrides.EnableJavaScript = &enableJavaScript
 	}
-
-	filePath := filepath.Join(buildDir, fileName)
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		err = os.MkdirAll(filepath.Dir(filePath), 0755)
-		ExitIfError(err)
-
-		ofile, err := os.Create(filePath)
-		ExitIfError(err)
-		defer func() {
-			ExitIfError(ofile.Close())
-		}()
-
-		response, err := http.Get(url)
-		ExitIfError(err)
-		defer func() {
-			ExitIfError(response.Body.Close())
-		}()
-
-		_, err = io.Copy(ofile, response.Body)
-		ExitIfError(err)
+	if isFlagSet("fts") {
+		docsetPackager.PlistOverrides.FullTextSearch = &fullTextSearch
 	}
+	docsetPackager.KeepVersions = keepVersions
+	docsetPackager.ArchiveHistoryDir = archiveHistoryDir
+	docsetPackager.Renderer = pageRenderer
+	return docsetPackager
+}
 
-	if wg != nil {
-		<-throttle
+// forEachTOC calls a function with the saved TOC of each locale and deliverable
+func forEachTOC(docBuilder *builder.Builder, locales []string, deliverables []string, version string, f func(toc *atlas.AtlasTOC) error) {
+	for _, locale := range locales {
+		for _, deliverable := range deliverables {
+			toc, err := docBuilder.LoadTOC(locale, deliverable, version)
+			ExitIfError(err)
+			ExitIfError(f(toc))
+		}
 	}
 }
 
-// getEntryType will return an entry type that should be used for a given entry and it's parent's type
-func getEntryType(entry TOCEntry, parentType SupportedType) (SupportedType, error) {
-	if parentType.ForceCascadeType {
-		return parentType.CreateChildType(), nil
+// startProgress displays the progress of a build until the returned function is called
+func startProgress(docBuilder *builder.Builder) (stop func()) {
+	if !showProgress {
+		return func() {}
 	}
-
-	childType, err := lookupEntryType(entry)
-	if err != nil && parentType.ShouldCascade() {
-		childType = parentType.CreateChildType()
-		err = nil
+	// Log messages are written to stderr, so the bar is drawn there too
+	info, err := os.Stderr.Stat()
+	terminal := err == nil && info.Mode()&os.ModeCharDevice != 0
+	if terminal {
+		return docBuilder.Progress.Display(os.Stderr, true, progressDrawInterval)
 	}
-
-	return childType, err
+	return docBuilder.Progress.Display(os.Stderr, false, progressLogInterval)
 }
 
-// lookupEntryType returns the matching SupportedType for a given entry or returns an error
-func lookupEntryType(entry TOCEntry) (SupportedType, error) {
-	for _, t := range SupportedTypes {
-		if entry.IsType(t) {
-			return t, nil
+// contains returns true if the value is in the list
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
 		}
 	}
-	return SupportedType{}, NewTypeNotFoundError(entry)
+	return false
 }
 
-// processEntryReference downloads html and indexes a toc item
-func processEntryReference(entry TOCEntry, entryType SupportedType, toc *AtlasTOC) {
-	LogDebug("Processing: %s", entry.Text)
-	throttle <- 1
-	wg.Add(1)
-
-	go downloadContent(entry, toc, &wg)
-
-	if entryType.ShouldSkipIndex() {
-		LogDebug("%s is a container or is hidden. Do not index", entry.Text)
-	} else if !entryType.IsValidType() {
-		LogDebug("No entry type for %s. Cannot index", entry.Text)
-	} else {
-		SaveSearchIndex(dbmap, entry, entryType, toc)
+func main() {
+	logging.Info("Starting...")
+	command, locales, version, allVersions, versionRange, deliverables, debug := parseFlags()
+	if debug {
+		logging.SetLevel(logging.DEBUG)
 	}
-}
 
-// entryHierarchy allows breadcrumb naming
-var entryHierarchy []string
+	pageRenderer, err := newRenderer()
+	ExitIfError(err)
 
-// processChildReferences iterates through all child toc items, cascading types, and indexes them
-func processChildReferences(entry TOCEntry, entryType SupportedType, toc *AtlasTOC) {
-	if entryType.PushName {
-		entryHierarchy = append(entryHierarchy, entry.CleanTitle(entryType))
-	}
+	// Share cached downloads between all builds and stages
+	docBuilder := builder.New(buildDir)
+	docBuilder.Renderer = pageRenderer
+	docBuilder.Concurrency = maxConcurrency
 
-	for _, child := range entry.Children {
-		LogDebug("Reading child: %s", child.Text)
-		var err error
-		var childType SupportedType
-		// Skip anything without an HTML page
-		if child.LinkAttr.Href != "" {
-			childType, err = getEntryType(child, entryType)
-			if err == nil {
-				processEntryReference(child, childType, toc)
-			} else {
-				WarnIfError(err)
-			}
-		} else {
-			LogDebug("%s has no link. Skipping", child.Text)
+	docsetPackager := newPackager(pageRenderer)
+
+	switch command {
+	case fetchCommand:
+		stopProgress := startProgress(docBuilder)
+		docBuilder.DownloadStylesheets()
+		for _, locale := range locales {
+			ExitIfError(docBuilder.FetchDeliverables(locale, deliverables, version))
 		}
-		if len(child.Children) > 0 {
-			processChildReferences(child, childType, toc)
+		ExitIfError(docBuilder.Wait())
+		stopProgress()
+
+	case renderCommand:
+		ExitIfError(docBuilder.PrepareRender())
+		stopProgress := startProgress(docBuilder)
+		forEachTOC(docBuilder, locales, deliverables, version, docBuilder.RenderDeliverable)
+		ExitIfError(docBuilder.Wait())
+		stopProgress()
+		ExitIfError(docBuilder.SaveSanitizeReport())
+
+	case indexCommand:
+		forEachTOC(docBuilder, locales, deliverables, version, docBuilder.IndexDeliverable)
+
+	case packageCommand:
+		for _, locale := range locales {
+			err := docsetPackager.Package(locale, deliverables, version)
+			ExitIfError(err)
 		}
-	}
-	LogDebug("Done processing children for %s", entry.Text)
-
-	if entryType.PushName {
-		entryHierarchy = entryHierarchy[:len(entryHierarchy)-1]
-	}
-}
-
-// downloadContent will download the html file for a given entry
-func downloadContent(entry TOCEntry, toc *AtlasTOC, wg *sync.WaitGroup) {
-	defer wg.Done()
 
-	filePath := entry.GetContentFilepath(toc, true)
-	// Prepend build dir
-	filePath = filepath.Join(buildDir, filePath)
-	// Make sure file doesn't exist first
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		content, err := entry.GetContent(toc)
-		ExitIfError(err)
+	case archiveCommand:
+		for _, locale := range locales {
+			for _, deliverable := range deliverables {
+				err := docsetPackager.ArchiveDocset(locale, deliverable, version)
+				ExitIfError(err)
+			}
+		}
 
-		err = os.MkdirAll(filepath.Dir(filePath), 0755)
+	case publishCommand:
+		// All archives are published in one pull request, so versions are checked with the first locale
+		err := docsetPackager.PublishArchives(locales[0], deliverables)
 		ExitIfError(err)
 
-		// TODO: Do something to format full page here
-
-		ofile, err := os.Create(filePath)
-		ExitIfError(err)
+	case reportCommand:
+		// Report on everything before failing
+		failed := false
+		for _, locale := range locales {
+			for _, deliverable := range deliverables {
+				err := docBuilder.ReportDeliverable(os.Stdout, locale, deliverable, version)
+				logging.WarnIfError(err)
+				failed = failed || err != nil
+			}
+		}
+		if failed {
+			ExitIfError(NewCustomError("Some deliverables are incomplete"))
+		}
 
-		header := "<meta http-equiv='Content-Type' content='text/html; charset=UTF-8' />" +
-			"<base href=\"../../\"/>\n"
-		for _, cssFile := range cssFiles {
-			header += fmt.Sprintf("<link rel=\"stylesheet\" type=\"text/css\" href=\"%s\">", cssFile)
+	// Diff takes two build dirs before any deliverables
+	case diffCommand:
+		if len(deliverables) < 2 {
+			ExitIfError(NewCustomError("diff requires an old and a new build dir"))
 		}
-		header += "<style>body { padding: 15px; }</style>"
-
-		defer func() {
-			ExitIfError(ofile.Close())
-		}()
-		_, err = ofile.WriteString(
-			header + content.Content,
-		)
+		err := builder.SaveBuildDiff(deliverables[0], deliverables[1], deliverables[2:], outDir)
 		ExitIfError(err)
-	}
-	<-throttle
-}
-
-func main() {
-	LogInfo("Starting...")
-	locale, deliverables, debug := parseFlags()
-	if debug {
-		SetLogLevel(DEBUG)
-	}
-
-	// Download CSS
-	for _, cssFile := range cssFiles {
-		throttle <- 1
-		wg.Add(1)
-		go downloadCSS(cssFile, &wg)
-	}
 
-	// Download icon
-	go downloadFile("https://developer.salesforce.com/resources2/favicon.ico", "icon.ico", nil)
+	// Without a command, every stage up to indexing is run
+	default:
+		ExitIfError(docBuilder.PrepareRender())
+		stopProgress := startProgress(docBuilder)
+		docBuilder.DownloadStylesheets()
 
-	// Init the Sqlite db
-	dbmap = InitDb(buildDir)
-	err := dbmap.TruncateTables()
-	ExitIfError(err)
-
-	for _, deliverable := range deliverables {
-		toc, err := getTOC(locale, deliverable)
-
-		err = verifyVersion(toc)
-		WarnIfError(err)
-
-		saveMainContent(toc)
-		saveContentVersion(toc)
-
-		// Download each entry
-		for _, entry := range toc.TOCEntries {
-			entryType, err := lookupEntryType(entry)
-			if err == nil {
-				processEntryReference(entry, entryType, toc)
+		for _, locale := range locales {
+			if allVersions {
+				err = docBuilder.BuildAllVersions(locale, deliverables, versionRange, docsetPackager)
+			} else {
+				err = docBuilder.BuildDeliverables(locale, deliverables, version)
 			}
-			processChildReferences(entry, entryType, toc)
+			ExitIfError(err)
 		}
+		stopProgress()
 
-		printSuccess(toc)
+		ExitIfError(docBuilder.SaveSanitizeReport())
 	}
-
-	wg.Wait()
 }
@@ -0,0 +1,218 @@
+package atlas
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/vividboarder/docset-sfdc/logging"
+)
+
+// Client retrieves TOCs and content from the Atlas API
+type Client struct {
+	// CacheDir is where successful content responses are cached so they can be shared between builds
+	// If empty, nothing is cached
+	CacheDir string
+	// OnContent is called once for every page retrieved, after any fallback, with the size of the response
+	// that was used and whether it was read from the cache
+	// It may be called from several goroutines at once
+	OnContent func(size int, cached bool)
+
+	// fallbackTOCs are default locale TOCs used for pages missing from translations, by deliverable and version
+	fallbackTOCs map[string]*AtlasTOC
+	fallbackLock sync.Mutex
+}
+
+// NewClient returns a Client caching content in a directory
+func NewClient(cacheDir string) *Client {
+	return &Client{CacheDir: cacheDir}
+}
+
+// CachePath returns the path a url is cached at
+func (client *Client) CachePath(url string) string {
+	hash := sha1.Sum([]byte(url))
+	return filepath.Join(client.CacheDir, hex.EncodeToString(hash[:]))
+}
+
+// getCached returns the body of a url, reading it from the cache if it was previously downloaded
+func (client *Client) getCached(url string) (contents []byte, status string, err error) {
+	if client.CacheDir != "" {
+		contents, err = ioutil.ReadFile(client.CachePath(url))
+		if err == nil {
+			logging.Debug("Read %s from cache", url)
+			status = "cached"
+			return
+		}
+	}
+
+	resp, err := http.Get(url)
+	if err != nil {
+		return
+	}
+	defer func() {
+		logging.WarnIfError(resp.Body.Close())
+	}()
+
+	status = resp.Status
+	contents, err = ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return
+	}
+
+	// Only cache successful responses
+	if client.CacheDir != "" && resp.StatusCode == http.StatusOK {
+		err = os.MkdirAll(client.CacheDir, 0755)
+		if err != nil {
+			return
+		}
+		err = ioutil.WriteFile(client.CachePath(url), contents, 0644)
+	}
+	return
+}
+
+// GetTOC Retrieves the TOC JSON and Unmarshals it
+// If docVersion is empty, the default version is retrieved
+func (client *Client) GetTOC(locale string, deliverable string, docVersion string) (toc *AtlasTOC, err error) {
+	docID := fmt.Sprintf("%s.%s", locale, deliverable)
+	if docVersion != "" {
+		docID = fmt.Sprintf("%s.%s.%s", locale, docVersion, deliverable)
+	}
+	var tocURL = fmt.Sprintf("https://developer.salesforce.com/docs/get_document/atlas.%s.meta", docID)
+	logging.Debug("TOC URL: %s", tocURL)
+	resp, err := http.Get(tocURL)
+	if err != nil {
+		return
+	}
+
+	// Read the downloaded JSON
+	defer func() {
+		logging.WarnIfError(resp.Body.Close())
+	}()
+	contents, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return
+	}
+
+	// Load into Struct
+	toc = new(AtlasTOC)
+	logging.Debug("TOC JSON: %s", string(contents))
+	err = json.Unmarshal([]byte(contents), toc)
+	return
+}
+
+// GetRequestedTOC retrieves the TOC for a requested version
+// If no version is requested, the default version is retrieved
+func (client *Client) GetRequestedTOC(locale string, deliverable string, requested string) (toc *AtlasTOC, err error) {
+	toc, err = client.GetTOC(locale, deliverable, "")
+	if err != nil || requested == "" {
+		return
+	}
+
+	version, err := FindVersion(toc.AvailableVersions, requested)
+	if err != nil {
+		return
+	}
+	if version.DocVersion == toc.Version.DocVersion {
+		return
+	}
+
+	toc, err = client.GetTOC(locale, deliverable, version.DocVersion)
+	if err != nil {
+		return
+	}
+	toc.VersionPinned = true
+	return
+}
+
+// GetContent retrieves Content for a TOCEntry from the API
+func (client *Client) GetContent(entry TOCEntry, toc *AtlasTOC) (*TOCContent, error) {
+	content, size, cached, err := client.getContent(entry, toc)
+	client.reportContent(content, size, cached)
+	return content, err
+}
+
+// getContent retrieves Content for a TOCEntry along with the size of the response and whether it was cached
+func (client *Client) getContent(entry TOCEntry, toc *AtlasTOC) (content *TOCContent, size int, cached bool, err error) {
+	if entry.GetRelLink(true) == "" {
+		return
+	}
+
+	url := entry.GetContentURL(toc)
+
+	contents, status, err := client.getCached(url)
+	if err != nil {
+		return
+	}
+	size, cached = len(contents), status == "cached"
+
+	// Load into Struct
+	content = new(TOCContent)
+	err = json.Unmarshal([]byte(contents), content)
+	if err != nil {
+		logging.Debug("Response from %s: %s", url, string(contents))
+		err = fmt.Errorf("Error reading JSON from %s (%s): %s", url, status, err.Error())
+	}
+	return
+}
+
+// reportContent calls OnContent for a page if a response was received for it
+func (client *Client) reportContent(content *TOCContent, size int, cached bool) {
+	if content != nil && client.OnContent != nil {
+		client.OnContent(size, cached)
+	}
+}
+
+// GetContentWithFallback retrieves content for an entry, falling back to the default locale
+// for pages that are missing in a translation
+// Only the response that is used is passed to OnContent
+func (client *Client) GetContentWithFallback(entry TOCEntry, toc *AtlasTOC) (*TOCContent, error) {
+	content, size, cached, err := client.getContent(entry, toc)
+	if toc.Locale == DefaultLocale || (err == nil && content != nil && content.Content != "") {
+		client.reportContent(content, size, cached)
+		return content, err
+	}
+
+	fallbackTOC, fallbackErr := client.getFallbackTOC(toc)
+	if fallbackErr != nil {
+		client.reportContent(content, size, cached)
+		return nil, fallbackErr
+	}
+	logging.Debug("%s is missing for %s. Falling back to %s %s", entry.Text, toc.Locale, DefaultLocale, fallbackTOC.Version.DocVersion)
+	content, size, cached, err = client.getContent(entry, fallbackTOC)
+	client.reportContent(content, size, cached)
+	return content, err
+}
+
+// getFallbackTOC returns the default locale TOC to fall back to for pages missing from a translated TOC
+// Translations aren't always published for the latest version, so the default locale's own version is
+// used unless the translated TOC was pinned to a version
+func (client *Client) getFallbackTOC(toc *AtlasTOC) (*AtlasTOC, error) {
+	requested := ""
+	if toc.VersionPinned {
+		requested = toc.Version.DocVersion
+	}
+	key := toc.Deliverable + "/" + requested
+
+	// Hold the lock while fetching so the TOC is only fetched once
+	client.fallbackLock.Lock()
+	defer client.fallbackLock.Unlock()
+	if fallbackTOC, ok := client.fallbackTOCs[key]; ok {
+		return fallbackTOC, nil
+	}
+
+	fallbackTOC, err := client.GetRequestedTOC(DefaultLocale, toc.Deliverable, requested)
+	if err != nil {
+		return nil, err
+	}
+	if client.fallbackTOCs == nil {
+		client.fallbackTOCs = map[string]*AtlasTOC{}
+	}
+	client.fallbackTOCs[key] = fallbackTOC
+	return fallbackTOC, nil
+}
@@ -0,0 +1,61 @@
+package atlas
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+// TestGetContentWithFallback checks that pages missing from a translation use the default locale's version
+func TestGetContentWithFallback(t *testing.T) {
+	dir, err := ioutil.TempDir("", "atlas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	client := NewClient(dir)
+
+	entry := TOCEntry{Text: "Intro", LinkAttr: LinkAttr{Href: "apex_intro.htm"}}
+	translated := &AtlasTOC{Deliverable: "apexcode", Locale: "ja-jp", Version: VersionInfo{DocVersion: "250.0"}}
+	fallback := &AtlasTOC{Deliverable: "apexcode", Locale: DefaultLocale, Version: VersionInfo{DocVersion: "252.0"}}
+	// The default locale TOC is known, so it isn't fetched
+	client.fallbackTOCs = map[string]*AtlasTOC{"apexcode/": fallback}
+
+	// Seed the cache with a missing translation and the page for both default locale versions
+	for toc, page := range map[*AtlasTOC]string{
+		translated: "",
+		fallback:   "<h1>Intro 252.0</h1>",
+		{Deliverable: "apexcode", Locale: DefaultLocale, Version: VersionInfo{DocVersion: "250.0"}}: "<h1>Intro 250.0</h1>",
+	} {
+		content, err := json.Marshal(TOCContent{Title: entry.Text, Content: page})
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = ioutil.WriteFile(client.CachePath(entry.GetContentURL(toc)), content, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Only the response that is used is counted
+	var calls, size int
+	client.OnContent = func(responseSize int, cached bool) {
+		calls++
+		size = responseSize
+	}
+
+	content, err := client.GetContentWithFallback(entry, translated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "<h1>Intro 252.0</h1>"; content.Content != expected {
+		t.Errorf("Fell back to %s, expected %s", content.Content, expected)
+	}
+	if calls != 1 {
+		t.Errorf("OnContent was called %d times, expected once", calls)
+	}
+	if expected, _ := json.Marshal(content); size != len(expected) {
+		t.Errorf("OnContent was called with %d bytes, expected the %d of the fallback", size, len(expected))
+	}
+}
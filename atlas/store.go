@@ -0,0 +1,79 @@
+package atlas
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SaveTOC will save the TOC to a JSON file in a build dir so it can be used in later steps
+func SaveTOC(dir string, toc *AtlasTOC) error {
+	filePath := filepath.Join(dir, toc.TOCFilename())
+	err := os.MkdirAll(filepath.Dir(filePath), 0755)
+	if err != nil {
+		return err
+	}
+
+	contents, err := json.Marshal(toc)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filePath, contents, 0644)
+}
+
+// ReadTOC reads a TOC from a JSON file
+func ReadTOC(filePath string) (*AtlasTOC, error) {
+	contents, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	toc := new(AtlasTOC)
+	err = json.Unmarshal(contents, toc)
+	if err != nil {
+		return nil, err
+	}
+	return toc, nil
+}
+
+// LoadTOC reads a TOC that was previously saved to a build dir with SaveTOC
+// If no version is requested, the default version is loaded
+func LoadTOC(dir string, locale string, deliverable string, requested string) (*AtlasTOC, error) {
+	pattern := filepath.Join(dir, fmt.Sprintf("atlas.%s.*%s.meta-toc.json", locale, deliverable))
+	tocPaths, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, tocPath := range tocPaths {
+		toc, err := ReadTOC(tocPath)
+		if err != nil {
+			return nil, err
+		}
+		if toc.Deliverable != deliverable {
+			continue
+		}
+
+		switch {
+		case requested == "" && !toc.VersionPinned:
+			return toc, nil
+		case requested == "":
+			continue
+		case strings.EqualFold(requested, LatestVersion):
+			if len(toc.AvailableVersions) > 0 && toc.AvailableVersions[0].DocVersion == toc.Version.DocVersion {
+				return toc, nil
+			}
+		case MatchesVersion(toc.Version, requested):
+			return toc, nil
+		}
+	}
+
+	if requested == "" {
+		return nil, fmt.Errorf("No TOC found matching %s", pattern)
+	}
+	return nil, fmt.Errorf("No TOC found matching %s for version %s", pattern, requested)
+}
@@ -0,0 +1,156 @@
+// Package atlas reads documentation from the Salesforce Atlas API
+package atlas
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultLocale is used for pages that are missing in other locales
+const DefaultLocale = "en-us"
+
+// JSON Structs
+
+// AtlasTOC represents the meta documenation from Salesforce
+type AtlasTOC struct {
+	AvailableVersions []VersionInfo `json:"available_versions"`
+	Content           string
+	ContentDocumentID string `json:"content_document_id"`
+	Deliverable       string
+	DocTitle          string `json:"doc_title"`
+	Locale            string
+	Language          LanguageInfo
+	PDFUrl            string     `json:"pdf_url"`
+	TOCEntries        []TOCEntry `json:"toc"`
+	Title             string
+	Version           VersionInfo
+	// Set when a version other than the default was requested
+	VersionPinned bool `json:"version_pinned,omitempty"`
+}
+
+// LanguageInfo contains information for linking and displaying the language
+type LanguageInfo struct {
+	Label  string
+	Locale string
+	URL    string
+}
+
+// VersionInfo representes a Salesforce documentation version
+type VersionInfo struct {
+	DocVersion     string `json:"doc_version"`
+	ReleaseVersion string `json:"release_version"`
+	VersionText    string `json:"version_text"`
+	VersionURL     string `json:"version_url"`
+}
+
+// TOCEntry represents a single Table of Contents item
+type TOCEntry struct {
+	Text                    string
+	ID                      string
+	LinkAttr                LinkAttr `json:"a_attr,omitempty"`
+	Children                []TOCEntry
+	ComputedFirstTopic      bool
+	ComputedResetPageLayout bool
+}
+
+// LinkAttr represents all attributes bound to a link
+type LinkAttr struct {
+	Href string
+}
+
+// TOCContent contains content information for a piece of documenation
+type TOCContent struct {
+	ID      string
+	Title   string
+	Content string
+}
+
+// GetRelLink extracts only the relative link from the Link Href
+func (entry TOCEntry) GetRelLink(removeAnchor bool) (relLink string) {
+	if entry.LinkAttr.Href == "" {
+		return
+	}
+
+	// Get the JSON file
+	relLink = entry.LinkAttr.Href
+	if removeAnchor {
+		anchorIndex := strings.LastIndex(relLink, "#")
+		if anchorIndex > 0 {
+			relLink = relLink[0:anchorIndex]
+		}
+	}
+	return
+}
+
+// GetContentURL returns the API url for the Content of this TOCEntry
+func (entry TOCEntry) GetContentURL(toc *AtlasTOC) string {
+	return fmt.Sprintf(
+		"https://developer.salesforce.com/docs/get_document_content/%s/%s/%s/%s",
+		toc.Deliverable,
+		entry.GetRelLink(true),
+		toc.Locale,
+		toc.Version.DocVersion,
+	)
+}
+
+// GetContentFilepath returns the filepath that should be used for the content
+// Entries without a link have no content and an empty path is returned
+func (entry TOCEntry) GetContentFilepath(toc *AtlasTOC, removeAnchor bool) string {
+	relLink := entry.GetRelLink(removeAnchor)
+	if relLink == "" {
+		return ""
+	}
+
+	return fmt.Sprintf("%s/%s/%s", toc.MetaDir(), toc.Deliverable, relLink)
+}
+
+// MetaDir returns the name of the directory containing all content for a deliverable
+// Pinned versions are kept apart from the default version, matching the Salesforce urls
+func (toc *AtlasTOC) MetaDir() string {
+	if toc.VersionPinned {
+		return fmt.Sprintf("atlas.%s.%s.%s.meta", toc.Locale, toc.Version.DocVersion, toc.Deliverable)
+	}
+	return fmt.Sprintf("atlas.%s.%s.meta", toc.Locale, toc.Deliverable)
+}
+
+// OutputName returns the deliverable name used for files in the build dir
+// Other locales and pinned versions are labeled so they don't clash with the default
+func (toc *AtlasTOC) OutputName() string {
+	name := toc.Deliverable
+	if toc.Locale != DefaultLocale {
+		name += "-" + toc.Locale
+	}
+	if toc.VersionPinned {
+		name += "-" + toc.Version.DocVersion
+	}
+	return name
+}
+
+// IndexPage returns the name of the landing page for a deliverable
+func (toc *AtlasTOC) IndexPage() string {
+	return toc.OutputName() + ".html"
+}
+
+// IndexFilename returns the name of the index for a deliverable in the build dir
+// Each deliverable, locale and version has it's own index so they can be built together
+func (toc *AtlasTOC) IndexFilename() string {
+	return toc.MetaDir() + ".dsidx"
+}
+
+// TOCFilename returns the name that the TOC for a deliverable is saved as in the build dir
+func (toc *AtlasTOC) TOCFilename() string {
+	return toc.MetaDir() + "-toc.json"
+}
+
+// VerifyVersion ensures that the version retrieved is the latest
+func (toc *AtlasTOC) VerifyVersion() error {
+	currentVersion := toc.Version.DocVersion
+	if len(toc.AvailableVersions) == 0 {
+		return fmt.Errorf("VerifyVersion: no versions are available")
+	}
+	topVersion := toc.AvailableVersions[0].DocVersion
+	if currentVersion != topVersion {
+		return fmt.Errorf("VerifyVersion: retrieved version is not the latest. Found %s, latest is %s", currentVersion, topVersion)
+	}
+	return nil
+}
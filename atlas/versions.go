@@ -0,0 +1,102 @@
+package atlas
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// LatestVersion can be requested to build the newest available version
+const LatestVersion = "latest"
+
+// MatchesVersion returns true if the requested version is the doc version, release version or version text
+func MatchesVersion(version VersionInfo, requested string) bool {
+	requested = strings.TrimSpace(requested)
+	if strings.EqualFold(requested, version.DocVersion) ||
+		strings.EqualFold(requested, version.ReleaseVersion) ||
+		strings.EqualFold(requested, version.VersionText) {
+		return true
+	}
+	// Allow 59 to match 59.0
+	if requested != "" && version.DocVersion != "" && strings.Trim(requested, "0123456789.") == "" {
+		return CompareVersions(requested, version.DocVersion) == 0
+	}
+	return false
+}
+
+// FindVersion returns the available version matching the requested version
+func FindVersion(versions []VersionInfo, requested string) (VersionInfo, error) {
+	if len(versions) == 0 {
+		return VersionInfo{}, errors.New("FindVersion: no versions are available")
+	}
+	if strings.EqualFold(requested, LatestVersion) {
+		return versions[0], nil
+	}
+	for _, version := range versions {
+		if MatchesVersion(version, requested) {
+			return version, nil
+		}
+	}
+
+	var available []string
+	for _, version := range versions {
+		available = append(available, fmt.Sprintf("%s (%s)", version.DocVersion, version.ReleaseVersion))
+	}
+	return VersionInfo{}, fmt.Errorf(
+		"FindVersion: version %s not found. Available versions: %s",
+		requested,
+		strings.Join(available, ", "),
+	)
+}
+
+// CompareVersions compares two doc versions like 48.0 numerically
+// It returns a negative number if a < b, 0 if they are equal and a positive number if a > b
+func CompareVersions(a string, b string) int {
+	aParts := strings.Split(a, ".")
+	bParts := strings.Split(b, ".")
+	for i := 0; i < len(aParts) || i < len(bParts); i++ {
+		var aPart, bPart float64
+		if i < len(aParts) {
+			aPart, _ = strconv.ParseFloat(aParts[i], 64)
+		}
+		if i < len(bParts) {
+			bPart, _ = strconv.ParseFloat(bParts[i], 64)
+		}
+		if aPart != bPart {
+			if aPart < bPart {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// ParseVersionRange parses a range formatted as min:max where either can be omitted
+func ParseVersionRange(versionRange string) (minVersion string, maxVersion string, err error) {
+	if versionRange == "" {
+		return
+	}
+	parts := strings.Split(versionRange, ":")
+	switch len(parts) {
+	case 1:
+		minVersion = parts[0]
+	case 2:
+		minVersion, maxVersion = parts[0], parts[1]
+	default:
+		err = fmt.Errorf("Invalid version range %s. Expected min:max", versionRange)
+	}
+	return
+}
+
+// InVersionRange returns true if a version is within a range. Empty bounds are unlimited
+func InVersionRange(version string, minVersion string, maxVersion string) bool {
+	if minVersion != "" && CompareVersions(version, minVersion) < 0 {
+		return false
+	}
+	if maxVersion != "" && CompareVersions(version, maxVersion) > 0 {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,301 @@
+// Package builder downloads, renders and indexes Atlas deliverables into a build dir
+// so they can be packaged into docsets
+package builder
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/vividboarder/docset-sfdc/atlas"
+	"github.com/vividboarder/docset-sfdc/classifier"
+	"github.com/vividboarder/docset-sfdc/logging"
+	"github.com/vividboarder/docset-sfdc/registry"
+	"github.com/vividboarder/docset-sfdc/renderer"
+)
+
+// DefaultConcurrency is the number of pages downloaded at once unless set otherwise
+const DefaultConcurrency = 16
+
+// Builder holds the state of a build into a single build dir
+// Pages are downloaded in the background, so Wait must be called before indexing or packaging
+type Builder struct {
+	// BuildDir is where everything is downloaded, rendered and indexed before packaging
+	BuildDir string
+	Client   *atlas.Client
+	Renderer *renderer.Renderer
+	// Concurrency is the number of pages downloaded at once
+	Concurrency int
+	// Progress counts the entries and pages processed by every stage
+	Progress *Progress
+
+	// throttle is the pool of download workers shared by every deliverable
+	throttle     chan int
+	throttleOnce sync.Once
+	// tasks are the background tasks that Wait blocks on
+	tasks taskGroup
+}
+
+// New returns a Builder for a build dir that caches downloads in the build dir
+func New(buildDir string) *Builder {
+	progress := NewProgress()
+	client := atlas.NewClient(filepath.Join(buildDir, "cache"))
+	client.OnContent = progress.addContent
+	return &Builder{
+		BuildDir:    buildDir,
+		Client:      client,
+		Renderer:    renderer.New(),
+		Concurrency: DefaultConcurrency,
+		Progress:    progress,
+	}
+}
+
+// withBuildDir returns a new Builder for another build dir sharing the client, renderer, progress and download pool
+func (builder *Builder) withBuildDir(buildDir string) *Builder {
+	return &Builder{
+		BuildDir:    buildDir,
+		Client:      builder.Client,
+		Renderer:    builder.Renderer,
+		Concurrency: builder.Concurrency,
+		Progress:    builder.Progress,
+		throttle:    builder.getThrottle(),
+	}
+}
+
+// Wait blocks until all background tasks are finished and returns the first error from any of them
+func (builder *Builder) Wait() error {
+	_, err := builder.tasks.wait()
+	return err
+}
+
+// walkTOC visits every entry of a TOC using the rules registered for the deliverable
+func walkTOC(toc *atlas.AtlasTOC, visit classifier.Visitor) {
+	classifier.NewWalker(registry.Get(toc.Deliverable).Types).Walk(toc, visit)
+}
+
+// LoadTOC reads a TOC that was previously saved into the build dir
+// If no version is requested, the default version is loaded
+func (builder *Builder) LoadTOC(locale string, deliverable string, version string) (*atlas.AtlasTOC, error) {
+	return atlas.LoadTOC(builder.BuildDir, locale, deliverable, version)
+}
+
+// downloadFile will download an aribtrary file to a given file path in the build dir
+// Existing files are kept
+func (builder *Builder) downloadFile(url string, fileName string) error {
+	filePath := filepath.Join(builder.BuildDir, fileName)
+	if _, err := os.Stat(filePath); !os.IsNotExist(err) {
+		return nil
+	}
+
+	err := os.MkdirAll(filepath.Dir(filePath), 0755)
+	if err != nil {
+		return err
+	}
+
+	response, err := http.Get(url)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		logging.WarnIfError(response.Body.Close())
+	}()
+
+	ofile, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(ofile, response.Body)
+	if closeErr := ofile.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// DownloadStylesheets downloads the Salesforce stylesheets and icon into the build dir in the background
+func (builder *Builder) DownloadStylesheets() {
+	// Download CSS
+	for _, cssFile := range renderer.CSSFiles {
+		cssFile := cssFile
+		builder.goThrottled(&builder.tasks, func() error {
+			return builder.downloadFile(renderer.CSSBaseURL+"/"+cssFile, cssFile)
+		})
+	}
+
+	// Download icon
+	builder.goThrottled(&builder.tasks, func() error {
+		return builder.downloadFile("https://developer.salesforce.com/resources2/favicon.ico", "icon.ico")
+	})
+}
+
+// PrepareRender saves the dark theme, highlighting and custom stylesheets into the build dir
+func (builder *Builder) PrepareRender() error {
+	return builder.Renderer.SaveStylesheets(builder.BuildDir)
+}
+
+// SaveSanitizeReport writes everything removed while sanitizing to the build dir
+func (builder *Builder) SaveSanitizeReport() error {
+	if !builder.Renderer.Sanitize {
+		return nil
+	}
+	return builder.Renderer.Report.Save(builder.BuildDir)
+}
+
+// saveLandingPage writes the landing page for a deliverable
+func (builder *Builder) saveLandingPage(toc *atlas.AtlasTOC) error {
+	filePath := filepath.Join(builder.BuildDir, toc.IndexPage())
+	// Always regenerate since it only depends on the TOC
+	err := os.MkdirAll(filepath.Dir(filePath), 0755)
+	if err != nil {
+		return err
+	}
+
+	ofile, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+
+	err = builder.Renderer.WriteLandingPage(ofile, toc)
+	if closeErr := ofile.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// saveContentVersion will retrieve the version number from the TOC and save that to a text file
+func (builder *Builder) saveContentVersion(toc *atlas.AtlasTOC) error {
+	filePath := filepath.Join(builder.BuildDir, toc.OutputName()+"-version.txt")
+	err := os.MkdirAll(filepath.Dir(filePath), 0755)
+	if err != nil {
+		return err
+	}
+
+	return writeFile(filePath, toc.Version.DocVersion)
+}
+
+// writeFile writes a string to a file, replacing any existing file
+func writeFile(filePath string, contents string) error {
+	ofile, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+
+	_, err = ofile.WriteString(contents)
+	if closeErr := ofile.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// renderPage will download and render the html file for a page of the TOC
+// Existing files are kept unless overwrite is set
+func (builder *Builder) renderPage(page *tocPage, toc *atlas.AtlasTOC, overwrite bool) error {
+	filePath := filepath.Join(builder.BuildDir, page.entry.GetContentFilepath(toc, true))
+	// Make sure file doesn't exist first
+	if _, err := os.Stat(filePath); !overwrite && !os.IsNotExist(err) {
+		builder.Progress.keepPage()
+		return nil
+	}
+
+	content, err := builder.Client.GetContentWithFallback(page.entry, toc)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(filePath), 0755)
+	if err != nil {
+		return err
+	}
+
+	rendered, err := builder.Renderer.RenderPage(
+		filePath, content.Content, page.entryType, page.entryType.CleanTitle(page.entry), page.sections,
+	)
+	if err != nil {
+		return err
+	}
+
+	return writeFile(filePath, rendered)
+}
+
+// saveTOC verifies the version of a TOC and saves it along with it's version into the build dir
+func (builder *Builder) saveTOC(toc *atlas.AtlasTOC) error {
+	if !toc.VersionPinned {
+		logging.WarnIfError(toc.VerifyVersion())
+	}
+
+	err := builder.saveContentVersion(toc)
+	if err != nil {
+		return err
+	}
+	return atlas.SaveTOC(builder.BuildDir, toc)
+}
+
+// BuildDeliverable downloads and renders all content for a deliverable in the background
+// It must be indexed once Wait returns
+func (builder *Builder) BuildDeliverable(toc *atlas.AtlasTOC) error {
+	return builder.buildDeliverable(toc, &builder.tasks)
+}
+
+// buildDeliverable downloads and renders all content for a deliverable as tasks of a group
+func (builder *Builder) buildDeliverable(toc *atlas.AtlasTOC, group *taskGroup) error {
+	err := builder.saveLandingPage(toc)
+	if err != nil {
+		return err
+	}
+	err = builder.saveTOC(toc)
+	if err != nil {
+		return err
+	}
+
+	builder.goEntries(toc, group, func(page *tocPage) error {
+		logging.Debug("Processing: %s", page.entry.Text)
+		return builder.renderPage(page, toc, false)
+	})
+	return nil
+}
+
+// buildTOC downloads and renders all content for a deliverable, then indexes it once
+// it's own downloads are finished
+func (builder *Builder) buildTOC(toc *atlas.AtlasTOC) error {
+	group := new(taskGroup)
+	err := builder.buildDeliverable(toc, group)
+	if err != nil {
+		return err
+	}
+	pages, err := group.wait()
+	if err != nil {
+		return err
+	}
+	logging.Info("Downloaded %d pages for %s", pages, toc.Deliverable)
+
+	return builder.IndexDeliverable(toc)
+}
+
+// BuildDeliverables builds a version of each deliverable into the build dir at the same time
+// Downloads for every deliverable share the pool of Concurrency workers and each deliverable
+// is indexed as soon as it's own downloads are finished
+// Other background tasks, like stylesheet downloads, are waited on before returning, even if a
+// deliverable fails, and the errors of every deliverable and task are returned together
+func (builder *Builder) BuildDeliverables(locale string, deliverables []string, version string) error {
+	logging.Info("Building %s", strings.Join(deliverables, ", "))
+	var finished int32
+	err := forEachDeliverable(deliverables, func(deliverable string) error {
+		toc, err := builder.Client.GetRequestedTOC(locale, deliverable, version)
+		if err != nil {
+			return fmt.Errorf("Could not get TOC for %s: %s", deliverable, err.Error())
+		}
+
+		err = builder.buildTOC(toc)
+		if err != nil {
+			return err
+		}
+		logging.Info("Finished %s (%d of %d)", deliverable, atomic.AddInt32(&finished, 1), len(deliverables))
+		return nil
+	})
+	return joinErrors(err, builder.Wait())
+}
@@ -0,0 +1,114 @@
+package builder
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/vividboarder/docset-sfdc/atlas"
+	"github.com/vividboarder/docset-sfdc/indexer"
+)
+
+// readTestTOC reads a fixture TOC from testdata
+func readTestTOC(t *testing.T, fileName string) *atlas.AtlasTOC {
+	toc, err := atlas.ReadTOC(filepath.Join("testdata", fileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return toc
+}
+
+// seedContentCache writes content for every entry into the download cache so nothing is fetched
+// Pages are given a heading unless their content is set by entry id
+func seedContentCache(t *testing.T, client *atlas.Client, toc *atlas.AtlasTOC, entries []atlas.TOCEntry, pages map[string]string) {
+	for _, entry := range entries {
+		// Entries linking to an anchor share the content of their page
+		if entry.LinkAttr.Href != "" && !strings.Contains(entry.LinkAttr.Href, "#") {
+			page, ok := pages[entry.ID]
+			if !ok {
+				page = "<h1>" + entry.Text + "</h1>"
+			}
+			content, err := json.Marshal(atlas.TOCContent{
+				ID:      entry.ID,
+				Title:   entry.Text,
+				Content: page,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = ioutil.WriteFile(client.CachePath(entry.GetContentURL(toc)), content, 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		seedContentCache(t, client, toc, entry.Children, pages)
+	}
+}
+
+// buildTestTOC builds and indexes a TOC into a temp dir from seeded content
+// The build dir should be removed once the test is done
+func buildTestTOC(t *testing.T, toc *atlas.AtlasTOC, pages map[string]string) *Builder {
+	tempDir, err := ioutil.TempDir("", "sfdashc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	builder := New(tempDir)
+	err = os.MkdirAll(builder.Client.CacheDir, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seedContentCache(t, builder.Client, toc, toc.TOCEntries, pages)
+
+	err = builder.BuildDeliverable(toc)
+	if err == nil {
+		err = builder.Wait()
+	}
+	if err == nil {
+		err = builder.IndexDeliverable(toc)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return builder
+}
+
+// checkIndexed checks that every expected name was indexed once with it's type and returns the indexed rows by name
+// Names expected with an empty type must not be indexed. The page of every indexed row must have been downloaded
+func checkIndexed(t *testing.T, builder *Builder, toc *atlas.AtlasTOC, expected map[string]string) map[string]indexer.SearchIndex {
+	index, err := indexer.Read(filepath.Join(builder.BuildDir, toc.IndexFilename()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	indexed := map[string]indexer.SearchIndex{}
+	counts := map[string]int{}
+	for _, row := range index {
+		indexed[row.Name] = row
+		counts[row.Name]++
+
+		page := strings.SplitN(row.Path, "#", 2)[0]
+		if _, err := os.Stat(filepath.Join(builder.BuildDir, page)); err != nil {
+			t.Errorf("%s was indexed but not downloaded: %s", row.Name, err.Error())
+		}
+	}
+
+	for name, typeName := range expected {
+		row, ok := indexed[name]
+		switch {
+		case typeName == "":
+			if ok {
+				t.Errorf("%s was indexed as a %s, expected it not to be indexed", name, row.Type)
+			}
+		case !ok:
+			t.Errorf("%s was not indexed", name)
+		case counts[name] != 1:
+			t.Errorf("%s was indexed %d times, expected once", name, counts[name])
+		case row.Type != typeName:
+			t.Errorf("%s was indexed as a %s, expected %s", name, row.Type, typeName)
+		}
+	}
+	return indexed
+}
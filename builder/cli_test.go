@@ -0,0 +1,53 @@
+package builder
+
+import (
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+)
+
+// projectCommandsPage documents several commands on one topic page
+// One section is wrapped in an element with it's id and the other follows a heading with it's id
+const projectCommandsPage = `<h1>project Commands</h1>
+<div id="cli_reference_project_deploy_start_unified">
+	<h2>project deploy start</h2>
+	<dl>
+		<dt>-o | --target-org TARGET-ORG</dt><dd>Username or alias of the target org.</dd>
+		<dt>--dry-run</dt><dd>Validate deploy and run Apex tests but don't save to the org.</dd>
+	</dl>
+</div>
+<h2 id="cli_reference_project_retrieve_start_unified">project retrieve start</h2>
+<dl>
+	<dt>-o | --target-org TARGET-ORG</dt><dd>Username or alias of the target org.</dd>
+</dl>`
+
+// TestBuildCLI checks that flags are indexed once under the command section they belong to
+func TestBuildCLI(t *testing.T) {
+	toc := readTestTOC(t, "cli-toc.json")
+	builder := buildTestTOC(t, toc, map[string]string{"cli_reference_project_commands_unified": projectCommandsPage})
+	defer os.RemoveAll(builder.BuildDir)
+
+	indexed := checkIndexed(t, builder, toc, map[string]string{
+		"project deploy start":                "Command",
+		"project retrieve start":              "Command",
+		"project deploy start --target-org":   "Option",
+		"project deploy start --dry-run":      "Option",
+		"project retrieve start --target-org": "Option",
+		"project Commands --target-org":       "",
+	})
+
+	optionCount := 0
+	for name, row := range indexed {
+		if row.Type != "Option" {
+			continue
+		}
+		optionCount++
+		if !strings.HasSuffix(row.Path, "cli_reference_project_commands_unified.htm#//apple_ref/cpp/Option/"+url.PathEscape(name)) {
+			t.Errorf("%s was indexed with path %s", name, row.Path)
+		}
+	}
+	if optionCount != 3 {
+		t.Errorf("Indexed %d options, expected 3", optionCount)
+	}
+}
@@ -0,0 +1,403 @@
+package builder
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/vividboarder/docset-sfdc/atlas"
+	"github.com/vividboarder/docset-sfdc/indexer"
+	"github.com/vividboarder/docset-sfdc/logging"
+	"golang.org/x/net/html"
+)
+
+// BuildDiff describes everything that changed between two builds
+type BuildDiff struct {
+	OldVersion   string                `json:"old_version"`
+	NewVersion   string                `json:"new_version"`
+	Added        []indexer.SearchIndex `json:"added"`
+	Removed      []indexer.SearchIndex `json:"removed"`
+	Renamed      []RenamedItem         `json:"renamed"`
+	AddedPages   []string              `json:"added_pages"`
+	RemovedPages []string              `json:"removed_pages"`
+	ChangedPages []string              `json:"changed_pages"`
+	MovedPages   []MovedPage           `json:"moved_pages"`
+}
+
+// RenamedItem is an index entry that kept it's page and type but changed names
+type RenamedItem struct {
+	Type    string `json:"type"`
+	OldName string `json:"old_name"`
+	NewName string `json:"new_name"`
+	Path    string `json:"path"`
+}
+
+// MovedPage is a page that is under a different parent in the TOC
+// Top level pages have an empty parent
+type MovedPage struct {
+	Page      string `json:"page"`
+	OldParent string `json:"old_parent"`
+	NewParent string `json:"new_parent"`
+}
+
+// tocParent identifies the parent of a page in the TOC
+type tocParent struct {
+	ID   string
+	Text string
+}
+
+// buildSnapshot is the index and TOC of a single build used for comparing
+type buildSnapshot struct {
+	Dir     string
+	Version string
+	Index   []indexer.SearchIndex
+	// Pages maps paths relative to the meta dir to paths in the build dir
+	Pages map[string]string
+	// Parents maps paths relative to the meta dir to the TOC entry the page is under
+	Parents map[string]tocParent
+}
+
+// normalizePath removes the meta dir from a path so it can be compared between versions and locales
+func normalizePath(path string) string {
+	parts := strings.SplitN(filepath.ToSlash(path), "/", 2)
+	if len(parts) < 2 {
+		return path
+	}
+	return parts[1]
+}
+
+// loadBuildSnapshot reads the index and all saved TOCs from a build dir
+func loadBuildSnapshot(dir string, deliverables []string) (snapshot buildSnapshot, err error) {
+	snapshot.Dir = dir
+	snapshot.Pages = map[string]string{}
+	snapshot.Parents = map[string]tocParent{}
+
+	tocPaths, err := filepath.Glob(filepath.Join(dir, "*-toc.json"))
+	if err != nil {
+		return
+	}
+	var versions []string
+	for _, tocPath := range tocPaths {
+		toc, readErr := atlas.ReadTOC(tocPath)
+		if readErr != nil {
+			err = readErr
+			return
+		}
+		if len(deliverables) > 0 && !contains(deliverables, toc.Deliverable) {
+			continue
+		}
+		versions = append(versions, toc.Version.DocVersion)
+		snapshot.addPages(toc.TOCEntries, tocParent{}, toc)
+
+		index, readErr := indexer.Read(filepath.Join(dir, toc.IndexFilename()))
+		if readErr != nil {
+			err = readErr
+			return
+		}
+		for _, row := range index {
+			row.ID = 0
+			row.Path = normalizePath(row.Path)
+			snapshot.Index = append(snapshot.Index, row)
+		}
+	}
+	if len(versions) == 0 {
+		err = fmt.Errorf("No TOC found in %s", dir)
+		return
+	}
+	snapshot.Version = strings.Join(versions, ", ")
+	return
+}
+
+// addPages adds the page and parent of every TOC entry to the snapshot
+// Pages shared by several entries keep the parent of the first one
+func (snapshot *buildSnapshot) addPages(entries []atlas.TOCEntry, parent tocParent, toc *atlas.AtlasTOC) {
+	for _, entry := range entries {
+		if entry.LinkAttr.Href != "" {
+			filePath := entry.GetContentFilepath(toc, true)
+			page := normalizePath(filePath)
+			if _, ok := snapshot.Pages[page]; !ok {
+				snapshot.Pages[page] = filepath.Join(snapshot.Dir, filePath)
+				snapshot.Parents[page] = parent
+			}
+		}
+		snapshot.addPages(entry.Children, tocParent{ID: entry.ID, Text: entry.Text}, toc)
+	}
+}
+
+// indexKey identifies an index entry by name and type
+func indexKey(row indexer.SearchIndex) string {
+	return row.Type + "\x00" + row.Name
+}
+
+// pathKey identifies an index entry by page and type
+func pathKey(row indexer.SearchIndex) string {
+	return row.Type + "\x00" + row.Path
+}
+
+// hashPage returns a hash of the text of a rendered page
+// The header, markup and anything added by sanitizing or highlighting is left out
+// so pages rendered with different settings can be compared
+func hashPage(filePath string) (string, error) {
+	ifile, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		logging.WarnIfError(ifile.Close())
+	}()
+
+	doc, err := html.Parse(ifile)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha1.New()
+	var hashText func(node *html.Node) error
+	hashText = func(node *html.Node) error {
+		if node.Type == html.ElementNode && contains(hiddenTags, node.Data) {
+			return nil
+		}
+		if node.Type == html.TextNode {
+			if text := strings.Join(strings.Fields(node.Data), " "); text != "" {
+				if _, err := io.WriteString(hash, text+"\n"); err != nil {
+					return err
+				}
+			}
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			if err := hashText(child); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	err = hashText(doc)
+	return fmt.Sprintf("%x", hash.Sum(nil)), err
+}
+
+// hiddenTags contain text that isn't shown as part of a page
+var hiddenTags = []string{"head", "noscript", "script", "style", "title"}
+
+// diffBuilds compares the index and pages of two builds
+func diffBuilds(oldBuild buildSnapshot, newBuild buildSnapshot) (diff BuildDiff, err error) {
+	diff.OldVersion = oldBuild.Version
+	diff.NewVersion = newBuild.Version
+
+	oldByKey := map[string]indexer.SearchIndex{}
+	for _, row := range oldBuild.Index {
+		oldByKey[indexKey(row)] = row
+	}
+	newByKey := map[string]indexer.SearchIndex{}
+	for _, row := range newBuild.Index {
+		newByKey[indexKey(row)] = row
+	}
+
+	var added, removed []indexer.SearchIndex
+	for key, row := range newByKey {
+		if _, ok := oldByKey[key]; !ok {
+			added = append(added, row)
+		}
+	}
+	for key, row := range oldByKey {
+		if _, ok := newByKey[key]; !ok {
+			removed = append(removed, row)
+		}
+	}
+
+	// Entries that were removed and added on the same page with the same type were renamed
+	addedByPath := map[string]indexer.SearchIndex{}
+	for _, row := range added {
+		addedByPath[pathKey(row)] = row
+	}
+	renamedPaths := map[string]bool{}
+	for _, row := range removed {
+		if newRow, ok := addedByPath[pathKey(row)]; ok && !renamedPaths[pathKey(row)] {
+			renamedPaths[pathKey(row)] = true
+			diff.Renamed = append(diff.Renamed, RenamedItem{
+				Type:    row.Type,
+				OldName: row.Name,
+				NewName: newRow.Name,
+				Path:    row.Path,
+			})
+			continue
+		}
+		diff.Removed = append(diff.Removed, row)
+	}
+	for _, row := range added {
+		if !renamedPaths[pathKey(row)] || addedByPath[pathKey(row)] != row {
+			diff.Added = append(diff.Added, row)
+		}
+	}
+
+	for page, newPath := range newBuild.Pages {
+		oldPath, ok := oldBuild.Pages[page]
+		if !ok {
+			diff.AddedPages = append(diff.AddedPages, page)
+			continue
+		}
+		if oldParent := oldBuild.Parents[page]; oldParent.ID != newBuild.Parents[page].ID {
+			diff.MovedPages = append(diff.MovedPages, MovedPage{
+				Page:      page,
+				OldParent: oldParent.Text,
+				NewParent: newBuild.Parents[page].Text,
+			})
+		}
+		oldHash, hashErr := hashPage(oldPath)
+		if hashErr != nil {
+			logging.Debug("Could not read %s: %s", oldPath, hashErr.Error())
+			continue
+		}
+		newHash, hashErr := hashPage(newPath)
+		if hashErr != nil {
+			logging.Debug("Could not read %s: %s", newPath, hashErr.Error())
+			continue
+		}
+		if oldHash != newHash {
+			diff.ChangedPages = append(diff.ChangedPages, page)
+		}
+	}
+	for page := range oldBuild.Pages {
+		if _, ok := newBuild.Pages[page]; !ok {
+			diff.RemovedPages = append(diff.RemovedPages, page)
+		}
+	}
+
+	diff.sort()
+	return
+}
+
+// sort orders every list in the diff so reports are stable
+func (diff *BuildDiff) sort() {
+	sortIndex := func(rows []indexer.SearchIndex) {
+		sort.Slice(rows, func(i, j int) bool {
+			if rows[i].Type != rows[j].Type {
+				return rows[i].Type < rows[j].Type
+			}
+			return rows[i].Name < rows[j].Name
+		})
+	}
+	sortIndex(diff.Added)
+	sortIndex(diff.Removed)
+	sort.Slice(diff.Renamed, func(i, j int) bool {
+		if diff.Renamed[i].Type != diff.Renamed[j].Type {
+			return diff.Renamed[i].Type < diff.Renamed[j].Type
+		}
+		return diff.Renamed[i].OldName < diff.Renamed[j].OldName
+	})
+	sort.Strings(diff.AddedPages)
+	sort.Strings(diff.RemovedPages)
+	sort.Strings(diff.ChangedPages)
+	sort.Slice(diff.MovedPages, func(i, j int) bool {
+		return diff.MovedPages[i].Page < diff.MovedPages[j].Page
+	})
+}
+
+// writeMarkdown writes a human readable report of the diff
+func (diff BuildDiff) writeMarkdown(w io.Writer) error {
+	lines := []string{
+		fmt.Sprintf("# Changes from %s to %s", diff.OldVersion, diff.NewVersion),
+		"",
+	}
+
+	addIndexSection := func(title string, rows []indexer.SearchIndex) {
+		lines = append(lines, fmt.Sprintf("## %s (%d)", title, len(rows)), "")
+		for _, row := range rows {
+			lines = append(lines, fmt.Sprintf("- %s `%s`", row.Type, row.Name))
+		}
+		lines = append(lines, "")
+	}
+	addPageSection := func(title string, pages []string) {
+		lines = append(lines, fmt.Sprintf("## %s (%d)", title, len(pages)), "")
+		for _, page := range pages {
+			lines = append(lines, fmt.Sprintf("- %s", page))
+		}
+		lines = append(lines, "")
+	}
+
+	addIndexSection("Added", diff.Added)
+	addIndexSection("Removed", diff.Removed)
+	lines = append(lines, fmt.Sprintf("## Renamed (%d)", len(diff.Renamed)), "")
+	for _, renamed := range diff.Renamed {
+		lines = append(lines, fmt.Sprintf("- %s `%s` to `%s`", renamed.Type, renamed.OldName, renamed.NewName))
+	}
+	lines = append(lines, "")
+	addPageSection("Added pages", diff.AddedPages)
+	addPageSection("Removed pages", diff.RemovedPages)
+	addPageSection("Changed pages", diff.ChangedPages)
+	lines = append(lines, fmt.Sprintf("## Moved pages (%d)", len(diff.MovedPages)), "")
+	for _, moved := range diff.MovedPages {
+		lines = append(lines, fmt.Sprintf("- %s from `%s` to `%s`", moved.Page, moved.OldParent, moved.NewParent))
+	}
+	lines = append(lines, "")
+
+	_, err := io.WriteString(w, strings.Join(lines, "\n"))
+	return err
+}
+
+// SaveBuildDiff compares two build dirs and writes Markdown and JSON reports to an output dir
+// If deliverables are given, only those are compared
+func SaveBuildDiff(oldDir string, newDir string, deliverables []string, outDir string) error {
+	oldBuild, err := loadBuildSnapshot(oldDir, deliverables)
+	if err != nil {
+		return err
+	}
+	newBuild, err := loadBuildSnapshot(newDir, deliverables)
+	if err != nil {
+		return err
+	}
+
+	diff, err := diffBuilds(oldBuild, newBuild)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(outDir, 0755)
+	if err != nil {
+		return err
+	}
+
+	mdPath := filepath.Join(outDir, "diff.md")
+	mdFile, err := os.Create(mdPath)
+	if err != nil {
+		return err
+	}
+	err = diff.writeMarkdown(mdFile)
+	if closeErr := mdFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	jsonPath := filepath.Join(outDir, "diff.json")
+	contents, err := json.MarshalIndent(diff, "", "    ")
+	if err != nil {
+		return err
+	}
+	err = ioutil.WriteFile(jsonPath, append(contents, '\n'), 0644)
+	if err != nil {
+		return err
+	}
+
+	logging.Info(
+		"%d added, %d removed, %d renamed, %d changed pages and %d moved pages. Reports written to %s and %s",
+		len(diff.Added), len(diff.Removed), len(diff.Renamed), len(diff.ChangedPages), len(diff.MovedPages), mdPath, jsonPath,
+	)
+	return nil
+}
+
+// contains returns true if the value is in the list
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
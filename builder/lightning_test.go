@@ -0,0 +1,57 @@
+package builder
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestBuildLightning builds the Aura docset from a fixture TOC and checks the index
+func TestBuildLightning(t *testing.T) {
+	toc := readTestTOC(t, "lightning-toc.json")
+	builder := buildTestTOC(t, toc, nil)
+	defer os.RemoveAll(builder.BuildDir)
+
+	// Every page was seeded into the cache
+	stats := builder.Progress.Stats()
+	if stats.Pages == 0 || stats.Done != stats.Pages || stats.Cached != stats.Pages || stats.Downloaded != 0 || stats.Kept != 0 || stats.Failed != 0 {
+		t.Errorf("Unexpected progress: %s", stats)
+	}
+
+	// Building again keeps the rendered pages without reading the cache
+	err := builder.BuildDeliverable(toc)
+	if err == nil {
+		err = builder.Wait()
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	rebuilt := builder.Progress.Stats()
+	if rebuilt.Pages != 2*stats.Pages || rebuilt.Kept != stats.Pages || rebuilt.Cached != stats.Cached {
+		t.Errorf("Unexpected progress after building again: %s", rebuilt)
+	}
+
+	// Every page linked from the landing page is downloaded, even without a type
+	report, err := builder.NewBuildReport(toc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.MissingPages) > 0 {
+		t.Errorf("Pages were not downloaded: %v", report.MissingPages)
+	}
+	untyped := toc.TOCEntries[0].GetContentFilepath(toc, true)
+	if _, err := os.Stat(filepath.Join(builder.BuildDir, untyped)); err != nil {
+		t.Errorf("%s has no type and was not downloaded: %s", untyped, err.Error())
+	}
+
+	checkIndexed(t, builder, toc, map[string]string{
+		"aura:iteration":    "Tag",
+		"force:recordData":  "Tag",
+		"force:showToast":   "Event",
+		"force:hasRecordId": "Interface",
+		// Everything under apps_intro is hidden, even entries with their own type
+		"Creating Apps":   "",
+		"Handling Events": "",
+		"Vendor Prefixes": "",
+	})
+}
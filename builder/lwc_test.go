@@ -0,0 +1,30 @@
+package builder
+
+import (
+	"os"
+	"testing"
+)
+
+// datatablePage is a component page in the guide with an attribute table
+const datatablePage = `<h1>lightning-datatable</h1>
+<table>
+	<tr><th>Attribute Name</th><th>Type</th><th>Description</th></tr>
+	<tr><td>key-field</td><td>string</td><td>Required. Associates each row with a unique ID.</td></tr>
+</table>`
+
+// TestBuildLWC builds the LWC docset from a fixture TOC and checks modules and guide pages are indexed
+func TestBuildLWC(t *testing.T) {
+	toc := readTestTOC(t, "lwc-toc.json")
+	builder := buildTestTOC(t, toc, map[string]string{"reference_lightning_datatable": datatablePage})
+	defer os.RemoveAll(builder.BuildDir)
+
+	checkIndexed(t, builder, toc, map[string]string{
+		"Get Started":           "Guide",
+		"Supported Browsers":    "Guide",
+		"lightning/uiRecordApi": "Module",
+		"@salesforce/apex":      "Module",
+		// Components and attributes are only documented in the Component Library
+		"lightning-datatable":           "Guide",
+		"lightning-datatable.key-field": "",
+	})
+}
@@ -0,0 +1,42 @@
+package builder
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+// accountPage is an object page with a field table and a table that doesn't list fields
+const accountPage = `<h1>Account</h1>
+<table>
+	<thead><tr><th>Field</th><th>Details</th></tr></thead>
+	<tbody>
+		<tr><td><span>AnnualRevenue</span></td><td>Type: currency</td></tr>
+		<tr><td>Custom__c</td><td>Type: string</td></tr>
+	</tbody>
+</table>
+<table>
+	<tr><th>Usage</th><th>Description</th></tr>
+	<tr><td>Ignored</td><td>Only field tables are indexed</td></tr>
+</table>`
+
+// TestBuildObjectReference checks that fields are indexed from the field table of an object
+func TestBuildObjectReference(t *testing.T) {
+	toc := readTestTOC(t, "object-reference-toc.json")
+	builder := buildTestTOC(t, toc, map[string]string{"sforce_api_objects_account": accountPage})
+	defer os.RemoveAll(builder.BuildDir)
+
+	indexed := checkIndexed(t, builder, toc, map[string]string{
+		"Account":               "Object",
+		"Account.AnnualRevenue": "Field",
+		"Account.Custom__c":     "Field",
+		"Account.Details":       "",
+		"Account.Ignored":       "",
+	})
+
+	for _, name := range []string{"Account.AnnualRevenue", "Account.Custom__c"} {
+		if !strings.HasSuffix(indexed[name].Path, "sforce_api_objects_account.htm#//apple_ref/cpp/Field/"+name) {
+			t.Errorf("%s was indexed with path %s", name, indexed[name].Path)
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package builder
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/vividboarder/docset-sfdc/logging"
+)
+
+// progressBarWidth is the number of characters in the progress bar drawn on a terminal
+const progressBarWidth = 30
+
+// Progress counts pages of every TOC as they are processed by the download workers
+// It is shared by every deliverable and version of a build, so counters are updated atomically
+type Progress struct {
+	// Counters are kept first so they are aligned for atomic access
+	pages      int64
+	done       int64
+	downloaded int64
+	cached     int64
+	kept       int64
+	failed     int64
+	bytes      int64
+
+	start time.Time
+}
+
+// ProgressStats is a snapshot of a Progress
+type ProgressStats struct {
+	// Pages is the number of pages queued, counting pages linked from several TOC entries once,
+	// and Done is how many are finished
+	Pages int64
+	Done  int64
+	// Downloaded pages were fetched from Atlas, Cached pages were read from the download cache and
+	// Kept pages were already rendered by a previous build, so they were not retrieved at all
+	Downloaded int64
+	Cached     int64
+	Kept       int64
+	// Failed pages could not be retrieved or rendered
+	Failed int64
+	// Bytes is the size of all downloaded pages
+	Bytes   int64
+	Elapsed time.Duration
+}
+
+// NewProgress returns a Progress that measures throughput from now
+func NewProgress() *Progress {
+	return &Progress{start: time.Now()}
+}
+
+// addPages adds pages that were queued to the total
+func (progress *Progress) addPages(count int) {
+	atomic.AddInt64(&progress.pages, int64(count))
+}
+
+// finishPage records that a page was processed
+func (progress *Progress) finishPage(err error) {
+	if err != nil {
+		atomic.AddInt64(&progress.failed, 1)
+	}
+	atomic.AddInt64(&progress.done, 1)
+}
+
+// addContent records a page that was downloaded or read from the download cache
+func (progress *Progress) addContent(size int, cached bool) {
+	if cached {
+		atomic.AddInt64(&progress.cached, 1)
+		return
+	}
+	atomic.AddInt64(&progress.downloaded, 1)
+	atomic.AddInt64(&progress.bytes, int64(size))
+}
+
+// keepPage records a page that was already rendered and is kept as is
+func (progress *Progress) keepPage() {
+	atomic.AddInt64(&progress.kept, 1)
+}
+
+// Stats returns a snapshot of the counters
+func (progress *Progress) Stats() ProgressStats {
+	return ProgressStats{
+		Pages:      atomic.LoadInt64(&progress.pages),
+		Done:       atomic.LoadInt64(&progress.done),
+		Downloaded: atomic.LoadInt64(&progress.downloaded),
+		Cached:     atomic.LoadInt64(&progress.cached),
+		Kept:       atomic.LoadInt64(&progress.kept),
+		Failed:     atomic.LoadInt64(&progress.failed),
+		Bytes:      atomic.LoadInt64(&progress.bytes),
+		Elapsed:    time.Since(progress.start),
+	}
+}
+
+// Percent returns how much of the queued pages are done
+func (stats ProgressStats) Percent() float64 {
+	if stats.Pages == 0 {
+		return 0
+	}
+	return 100 * float64(stats.Done) / float64(stats.Pages)
+}
+
+// ETA estimates the time until all queued pages are done from the rate pages have been done so far
+// It returns false until a page is done
+func (stats ProgressStats) ETA() (time.Duration, bool) {
+	if stats.Done == 0 || stats.Elapsed <= 0 {
+		return 0, false
+	}
+	perPage := stats.Elapsed / time.Duration(stats.Done)
+	return perPage * time.Duration(stats.Pages-stats.Done), true
+}
+
+// String formats the counters, throughput and ETA on a single line
+func (stats ProgressStats) String() string {
+	seconds := stats.Elapsed.Seconds()
+	if seconds <= 0 {
+		seconds = 1
+	}
+	eta := "unknown"
+	if remaining, ok := stats.ETA(); ok {
+		eta = remaining.Round(time.Second).String()
+	}
+	return fmt.Sprintf(
+		"%d/%d pages (%.0f%%), %d downloaded, %d cached, %d kept, %d failed, %s at %s/s, %.1f pages/s, ETA %s",
+		stats.Done, stats.Pages, stats.Percent(),
+		stats.Downloaded, stats.Cached, stats.Kept, stats.Failed,
+		formatBytes(stats.Bytes), formatBytes(int64(float64(stats.Bytes)/seconds)),
+		float64(stats.Done)/seconds, eta,
+	)
+}
+
+// bar draws the percent done as a bar followed by the stats
+func (stats ProgressStats) bar() string {
+	filled := int(stats.Percent() * progressBarWidth / 100)
+	return fmt.Sprintf(
+		"[%s%s] %s",
+		strings.Repeat("=", filled), strings.Repeat(" ", progressBarWidth-filled), stats,
+	)
+}
+
+// formatBytes returns a size in the largest unit that keeps it above 1
+func formatBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	value := float64(size) / unit
+	units := "KMGT"
+	i := 0
+	for ; value >= unit && i < len(units)-1; i++ {
+		value /= unit
+	}
+	return fmt.Sprintf("%.1f %cB", value, units[i])
+}
+
+// terminalWriter redraws the progress bar below every log message written to a terminal
+type terminalWriter struct {
+	lock     sync.Mutex
+	out      io.Writer
+	progress *Progress
+}
+
+// Write clears the bar, writes the message and draws the bar again
+func (writer *terminalWriter) Write(message []byte) (int, error) {
+	writer.lock.Lock()
+	defer writer.lock.Unlock()
+	if _, err := io.WriteString(writer.out, "\r\033[K"); err != nil {
+		return 0, err
+	}
+	n, err := writer.out.Write(message)
+	if err != nil {
+		return n, err
+	}
+	_, err = io.WriteString(writer.out, writer.progress.Stats().bar())
+	return n, err
+}
+
+// draw redraws the bar in place
+func (writer *terminalWriter) draw() error {
+	writer.lock.Lock()
+	defer writer.lock.Unlock()
+	_, err := io.WriteString(writer.out, "\r\033[K"+writer.progress.Stats().bar())
+	return err
+}
+
+// Display prints the progress every interval until the returned function is called
+// On a terminal a bar is redrawn in place below log messages, otherwise a log line is printed
+func (progress *Progress) Display(out io.Writer, terminal bool, interval time.Duration) (stop func()) {
+	var tick func()
+	var finish func()
+	if terminal {
+		writer := &terminalWriter{out: out, progress: progress}
+		previous := logging.Writer()
+		logging.SetOutput(writer)
+		tick = func() {
+			logging.WarnIfError(writer.draw())
+		}
+		finish = func() {
+			err := writer.draw()
+			logging.SetOutput(previous)
+			if err == nil {
+				_, err = io.WriteString(out, "\n")
+			}
+			logging.WarnIfError(err)
+		}
+	} else {
+		tick = func() {
+			if stats := progress.Stats(); stats.Pages > 0 {
+				logging.Info("Progress: %s", stats)
+			}
+		}
+		finish = tick
+	}
+
+	ticker := time.NewTicker(interval)
+	stopped := make(chan bool)
+	finished := make(chan bool)
+	go func() {
+		defer close(finished)
+		for {
+			select {
+			case <-ticker.C:
+				tick()
+			case <-stopped:
+				ticker.Stop()
+				finish()
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(stopped)
+			<-finished
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package builder
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/vividboarder/docset-sfdc/atlas"
+	"github.com/vividboarder/docset-sfdc/classifier"
+	"github.com/vividboarder/docset-sfdc/indexer"
+	"github.com/vividboarder/docset-sfdc/registry"
+)
+
+// BuildReport summarizes the state of a deliverable in the build dir
+type BuildReport struct {
+	Name         string
+	Deliverable  string
+	Locale       string
+	DocVersion   string
+	Pages        int
+	MissingPages []string
+	Indexed      int
+	IndexedTypes map[string]int
+}
+
+// NewBuildReport counts the pages and index entries of a deliverable in the build dir
+func (builder *Builder) NewBuildReport(toc *atlas.AtlasTOC) (report BuildReport, err error) {
+	report = BuildReport{
+		Name:         registry.GetDocsetTitle(toc),
+		Deliverable:  toc.Deliverable,
+		Locale:       toc.Locale,
+		DocVersion:   toc.Version.DocVersion,
+		IndexedTypes: map[string]int{},
+	}
+
+	pages := map[string]bool{}
+	// Every page is downloaded, even if it has no type to index it by
+	walkTOC(toc, func(entry atlas.TOCEntry, entryType classifier.SupportedType, breadcrumb classifier.Breadcrumb, err error) {
+		filePath := entry.GetContentFilepath(toc, true)
+		if pages[filePath] {
+			return
+		}
+		pages[filePath] = true
+		if _, statErr := os.Stat(filepath.Join(builder.BuildDir, filePath)); os.IsNotExist(statErr) {
+			report.MissingPages = append(report.MissingPages, filePath)
+		}
+	})
+	report.Pages = len(pages)
+	sort.Strings(report.MissingPages)
+
+	index, err := indexer.Read(filepath.Join(builder.BuildDir, toc.IndexFilename()))
+	if os.IsNotExist(err) {
+		// Not indexed yet
+		return report, nil
+	}
+	if err != nil {
+		return
+	}
+	report.Indexed = len(index)
+	for _, row := range index {
+		report.IndexedTypes[row.Type]++
+	}
+	return
+}
+
+// Write writes a human readable summary of the report
+func (report BuildReport) Write(w io.Writer) error {
+	lines := []string{
+		fmt.Sprintf("%s (%s %s %s)", report.Name, report.Deliverable, report.Locale, report.DocVersion),
+		fmt.Sprintf("  Pages: %d rendered, %d missing", report.Pages-len(report.MissingPages), len(report.MissingPages)),
+	}
+	for _, page := range report.MissingPages {
+		lines = append(lines, fmt.Sprintf("    %s", page))
+	}
+	lines = append(lines, fmt.Sprintf("  Index: %d entries", report.Indexed))
+
+	var types []string
+	for typeName := range report.IndexedTypes {
+		types = append(types, typeName)
+	}
+	sort.Strings(types)
+	for _, typeName := range types {
+		lines = append(lines, fmt.Sprintf("    %s: %d", typeName, report.IndexedTypes[typeName]))
+	}
+
+	for _, line := range lines {
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReportDeliverable writes a summary of a deliverable in the build dir
+// An error is returned if any page is missing so a failing stage can be detected
+func (builder *Builder) ReportDeliverable(w io.Writer, locale string, deliverable string, version string) error {
+	toc, err := builder.LoadTOC(locale, deliverable, version)
+	if err != nil {
+		return fmt.Errorf("Cannot report on %s. Could not read TOC: %s", deliverable, err.Error())
+	}
+
+	report, err := builder.NewBuildReport(toc)
+	if err != nil {
+		return err
+	}
+	err = report.Write(w)
+	if err != nil {
+		return err
+	}
+
+	if len(report.MissingPages) > 0 {
+		return fmt.Errorf("%s is missing %d pages", deliverable, len(report.MissingPages))
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package builder
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/vividboarder/docset-sfdc/atlas"
+	"github.com/vividboarder/docset-sfdc/classifier"
+	"github.com/vividboarder/docset-sfdc/indexer"
+	"github.com/vividboarder/docset-sfdc/logging"
+	"github.com/vividboarder/docset-sfdc/renderer"
+)
+
+// Each stage can be run on it's own over a shared build dir
+// fetch downloads the TOC and all content into the cache, render writes pages from the cache
+// and index builds the search index from the TOC and rendered pages
+
+// FetchDeliverable saves the TOC for a deliverable and downloads all of it's content into the cache
+// Downloads run in the background until Wait returns
+func (builder *Builder) FetchDeliverable(toc *atlas.AtlasTOC) error {
+	err := builder.saveTOC(toc)
+	if err != nil {
+		return err
+	}
+
+	builder.goEntries(toc, &builder.tasks, func(page *tocPage) error {
+		logging.Debug("Fetching: %s", page.entry.Text)
+		_, err := builder.Client.GetContentWithFallback(page.entry, toc)
+		return err
+	})
+	return nil
+}
+
+// FetchDeliverables gets the TOC of each deliverable at the same time and downloads all of their
+// content into the cache using the shared pool of workers
+// Downloads run in the background until Wait returns
+func (builder *Builder) FetchDeliverables(locale string, deliverables []string, version string) error {
+	return forEachDeliverable(deliverables, func(deliverable string) error {
+		toc, err := builder.Client.GetRequestedTOC(locale, deliverable, version)
+		if err != nil {
+			return fmt.Errorf("Could not get TOC for %s: %s", deliverable, err.Error())
+		}
+		return builder.FetchDeliverable(toc)
+	})
+}
+
+// RenderDeliverable writes the landing page and every page of a saved TOC, replacing existing pages
+// Pages are rendered in the background until Wait returns
+func (builder *Builder) RenderDeliverable(toc *atlas.AtlasTOC) error {
+	err := builder.saveLandingPage(toc)
+	if err != nil {
+		return err
+	}
+
+	builder.goEntries(toc, &builder.tasks, func(page *tocPage) error {
+		logging.Debug("Rendering: %s", page.entry.Text)
+		return builder.renderPage(page, toc, true)
+	})
+	return nil
+}
+
+// IndexDeliverable builds the search index for a deliverable from it's TOC and rendered pages
+func (builder *Builder) IndexDeliverable(toc *atlas.AtlasTOC) (err error) {
+	// Init the Sqlite db
+	index, err := indexer.Create(filepath.Join(builder.BuildDir, toc.IndexFilename()))
+	if err != nil {
+		return
+	}
+	defer func() {
+		if closeErr := index.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	// Entries linking to anchors of the same page share it's content entries, so each page is only parsed once
+	parsedPages := map[string]bool{}
+	walkTOC(toc, func(entry atlas.TOCEntry, entryType classifier.SupportedType, breadcrumb classifier.Breadcrumb, typeErr error) {
+		if err != nil {
+			return
+		}
+		if typeErr != nil {
+			logging.WarnIfError(typeErr)
+			return
+		}
+
+		if entryType.ShouldSkipIndex() {
+			logging.Debug("%s is a container or is hidden. Do not index", entry.Text)
+		} else if !entryType.IsValidType() {
+			logging.Debug("No entry type for %s. Cannot index", entry.Text)
+		} else {
+			err = index.Add(entryType.EntryName(entry, breadcrumb), entryType.TypeName, entry.GetContentFilepath(toc, false))
+			if err != nil {
+				return
+			}
+		}
+
+		// Entries in the content are found using the anchors added when rendering
+		if entryType.ShouldParseContent() && !parsedPages[entry.GetContentFilepath(toc, true)] {
+			parsedPages[entry.GetContentFilepath(toc, true)] = true
+			err = builder.indexContent(index, entry, entryType, toc)
+		}
+	})
+	if err != nil {
+		return
+	}
+
+	logging.Info("Success: %s - %s - %s", toc.DocTitle, toc.Version.VersionText, toc.Version.DocVersion)
+	return
+}
+
+// indexContent indexes the entries anchored in the rendered page of an entry
+func (builder *Builder) indexContent(index *indexer.Index, entry atlas.TOCEntry, entryType classifier.SupportedType, toc *atlas.AtlasTOC) error {
+	relLink := entry.GetContentFilepath(toc, true)
+	filePath := filepath.Join(builder.BuildDir, relLink)
+	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		return fmt.Errorf("Cannot index content of %s. It must be rendered first", entry.Text)
+	}
+
+	anchors, err := renderer.ReadContentAnchors(filePath, entryType.ContentTypeName)
+	if err != nil {
+		return err
+	}
+
+	for _, anchor := range anchors {
+		typeName, name, ok := renderer.ParseDashAnchor(anchor)
+		if !ok {
+			continue
+		}
+		err = index.Add(name, typeName, relLink+"#"+anchor)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package builder
+
+import (
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/vividboarder/docset-sfdc/atlas"
+	"github.com/vividboarder/docset-sfdc/classifier"
+	"github.com/vividboarder/docset-sfdc/logging"
+)
+
+// taskGroup tracks a set of background tasks and keeps the first error from any of them
+type taskGroup struct {
+	wg    sync.WaitGroup
+	lock  sync.Mutex
+	err   error
+	count int
+}
+
+// add records that a task was started
+func (group *taskGroup) add() {
+	group.lock.Lock()
+	defer group.lock.Unlock()
+	group.count++
+	group.wg.Add(1)
+}
+
+// done records that a task finished, keeping it's error if it was the first
+func (group *taskGroup) done(err error) {
+	defer group.wg.Done()
+	if err == nil {
+		return
+	}
+
+	group.lock.Lock()
+	defer group.lock.Unlock()
+	if group.err == nil {
+		group.err = err
+	} else {
+		logging.Warning(err.Error())
+	}
+}
+
+// wait blocks until all tasks are finished and returns the number of tasks and the first error
+// The group is reset so it can be used again
+func (group *taskGroup) wait() (int, error) {
+	group.wg.Wait()
+
+	group.lock.Lock()
+	defer group.lock.Unlock()
+	count, err := group.count, group.err
+	group.count, group.err = 0, nil
+	return count, err
+}
+
+// getThrottle returns the pool of download workers, creating it on first use
+func (builder *Builder) getThrottle() chan int {
+	builder.throttleOnce.Do(func() {
+		if builder.throttle != nil {
+			return
+		}
+		concurrency := builder.Concurrency
+		if concurrency < 1 {
+			concurrency = 1
+		}
+		builder.throttle = make(chan int, concurrency)
+	})
+	return builder.throttle
+}
+
+// goThrottled runs a task of a group in the background once a download worker is free
+func (builder *Builder) goThrottled(group *taskGroup, task func() error) {
+	throttle := builder.getThrottle()
+	throttle <- 1
+	group.add()
+	go func() {
+		defer func() {
+			<-throttle
+		}()
+		group.done(task())
+	}()
+}
+
+// tocPage is a page of a TOC along with every entry that links to it
+type tocPage struct {
+	// entry is the first entry linking to the page, unless a later one parses the content
+	entry     atlas.TOCEntry
+	entryType classifier.SupportedType
+	// sections maps anchors in the page to the names of the entries linking to them
+	sections map[string]string
+}
+
+// goEntries runs a task for every page of a TOC as part of a group
+// Entries without a type are included so every page linked from the landing page is downloaded
+// Entries that link to anchors of the same page share it, so the task only runs once per page
+// Pages are counted before any are run so the progress has a total to estimate from
+func (builder *Builder) goEntries(toc *atlas.AtlasTOC, group *taskGroup, task func(page *tocPage) error) {
+	var pages []*tocPage
+	pagesByPath := map[string]*tocPage{}
+	walkTOC(toc, func(entry atlas.TOCEntry, entryType classifier.SupportedType, breadcrumb classifier.Breadcrumb, err error) {
+		filePath := entry.GetContentFilepath(toc, true)
+		page, ok := pagesByPath[filePath]
+		if !ok {
+			page = &tocPage{entry: entry, entryType: entryType, sections: map[string]string{}}
+			pagesByPath[filePath] = page
+			pages = append(pages, page)
+		} else if !page.entryType.ShouldParseContent() && entryType.ShouldParseContent() {
+			page.entry, page.entryType = entry, entryType
+		}
+
+		if anchorIndex := strings.LastIndex(entry.LinkAttr.Href, "#"); anchorIndex >= 0 {
+			page.sections[entry.LinkAttr.Href[anchorIndex+1:]] = entryType.EntryName(entry, breadcrumb)
+		}
+	})
+
+	builder.Progress.addPages(len(pages))
+	for _, page := range pages {
+		page := page
+		builder.goThrottled(group, func() error {
+			err := task(page)
+			builder.Progress.finishPage(err)
+			return err
+		})
+	}
+}
+
+// forEachDeliverable runs a function for every deliverable at the same time
+// Every deliverable is run to completion and the errors of all of them are returned together
+func forEachDeliverable(deliverables []string, f func(deliverable string) error) error {
+	errs := make(chan error, len(deliverables))
+	for _, deliverable := range deliverables {
+		go func(deliverable string) {
+			errs <- f(deliverable)
+		}(deliverable)
+	}
+
+	var allErrs []error
+	for range deliverables {
+		allErrs = append(allErrs, <-errs)
+	}
+	return joinErrors(allErrs...)
+}
+
+// joinErrors returns an error with the message of every error that isn't nil, or nil if they all are
+func joinErrors(errs ...error) error {
+	var messages []string
+	for _, err := range errs {
+		if err != nil {
+			messages = append(messages, err.Error())
+		}
+	}
+	if len(messages) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(messages, "\n"))
+}
@@ -0,0 +1,39 @@
+package builder
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// TestForEachDeliverable checks that every deliverable runs when some fail and that all errors are returned
+func TestForEachDeliverable(t *testing.T) {
+	var lock sync.Mutex
+	ran := map[string]bool{}
+	err := forEachDeliverable([]string{"apexcode", "pages", "lightning"}, func(deliverable string) error {
+		lock.Lock()
+		ran[deliverable] = true
+		lock.Unlock()
+		if deliverable == "lightning" {
+			return nil
+		}
+		return errors.New("Could not get TOC for " + deliverable)
+	})
+
+	if len(ran) != 3 {
+		t.Errorf("Ran %v, expected every deliverable", ran)
+	}
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+	for _, deliverable := range []string{"apexcode", "pages"} {
+		if !strings.Contains(err.Error(), "Could not get TOC for "+deliverable) {
+			t.Errorf("Error %q is missing %s", err.Error(), deliverable)
+		}
+	}
+
+	if err := joinErrors(nil, nil); err != nil {
+		t.Errorf("Joined no errors into %s", err.Error())
+	}
+}
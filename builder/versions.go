@@ -0,0 +1,83 @@
+package builder
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/vividboarder/docset-sfdc/atlas"
+	"github.com/vividboarder/docset-sfdc/logging"
+	"github.com/vividboarder/docset-sfdc/packager"
+)
+
+// GetVersionBuildDir returns the build dir used for a single version when building all versions
+func GetVersionBuildDir(rootBuildDir string, docVersion string) string {
+	return filepath.Join(rootBuildDir, packager.VersionsDir, docVersion)
+}
+
+// BuildAllVersions builds and packages every available version of the deliverables
+// Each version gets it's own build dir, sharing stylesheets and cached downloads
+func (builder *Builder) BuildAllVersions(locale string, deliverables []string, versionRange string, pkg *packager.Packager) error {
+	minVersion, maxVersion, err := atlas.ParseVersionRange(versionRange)
+	if err != nil {
+		return err
+	}
+
+	// Find which deliverables are available for each version
+	deliverablesByVersion := map[string][]string{}
+	var docVersions []string
+	for _, deliverable := range deliverables {
+		toc, err := builder.Client.GetTOC(locale, deliverable, "")
+		if err != nil {
+			return err
+		}
+
+		for _, version := range toc.AvailableVersions {
+			if !atlas.InVersionRange(version.DocVersion, minVersion, maxVersion) {
+				continue
+			}
+			if _, ok := deliverablesByVersion[version.DocVersion]; !ok {
+				docVersions = append(docVersions, version.DocVersion)
+			}
+			deliverablesByVersion[version.DocVersion] = append(deliverablesByVersion[version.DocVersion], deliverable)
+		}
+	}
+	if len(docVersions) == 0 {
+		return fmt.Errorf("No versions available in range %s", versionRange)
+	}
+
+	// Newest versions first
+	sort.Slice(docVersions, func(i, j int) bool {
+		return atlas.CompareVersions(docVersions[i], docVersions[j]) > 0
+	})
+	logging.Info("Building versions: %s", strings.Join(docVersions, ", "))
+
+	// Stylesheets must be downloaded before they can be shared
+	err = builder.Wait()
+	if err != nil {
+		return err
+	}
+
+	for _, docVersion := range docVersions {
+		versionBuilder := builder.withBuildDir(GetVersionBuildDir(builder.BuildDir, docVersion))
+		err = packager.CopyStylesheets(builder.BuildDir, versionBuilder.BuildDir)
+		if err != nil {
+			return err
+		}
+
+		versionDeliverables := deliverablesByVersion[docVersion]
+		err = versionBuilder.BuildDeliverables(locale, versionDeliverables, docVersion)
+		if err != nil {
+			return err
+		}
+
+		versionPackager := *pkg
+		versionPackager.BuildDir = versionBuilder.BuildDir
+		err = versionPackager.Package(locale, versionDeliverables, docVersion)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -1,4 +1,4 @@
-package main
+package classifier
 
 var SupportedTypes = []SupportedType{
 	// ID Based overrides should come first
@@ -294,3 +294,152 @@ var SupportedTypes = []SupportedType{
 		CascadeType: true,
 	},
 }
+
+// RestAPITypes are rules for the REST API Developer Guide
+var RestAPITypes = []SupportedType{
+	SupportedType{
+		TypeName:    "Guide",
+		IDPrefix:    "intro_",
+		CascadeType: true,
+	},
+	SupportedType{
+		TypeName: "Guide",
+		IDPrefix: "dome_",
+		NoTrim:   true,
+	},
+	SupportedType{
+		TypeName: "Resource",
+		IDPrefix: "resources_",
+		NoTrim:   true,
+	},
+	SupportedType{
+		TypeName: "Parameter",
+		IDPrefix: "headers_",
+		NoTrim:   true,
+	},
+	SupportedType{
+		TypeName: "Error",
+		ID:       "errorcodes",
+		NoTrim:   true,
+	},
+}
+
+// SOQLTypes are rules for the SOQL and SOSL Reference
+var SOQLTypes = []SupportedType{
+	SupportedType{
+		TypeName: "Function",
+		IDPrefix: "sforce_api_calls_soql_select_agg_functions",
+		NoTrim:   true,
+	},
+	SupportedType{
+		TypeName: "Function",
+		IDPrefix: "sforce_api_calls_soql_select_date_functions",
+		NoTrim:   true,
+	},
+	SupportedType{
+		TypeName: "Keyword",
+		IDPrefix: "sforce_api_calls_soql_select_",
+		NoTrim:   true,
+	},
+	SupportedType{
+		TypeName: "Keyword",
+		IDPrefix: "sforce_api_calls_sosl_",
+		NoTrim:   true,
+	},
+	SupportedType{
+		TypeName: "Guide",
+		IDPrefix: "sforce_api_calls_soql",
+		NoTrim:   true,
+	},
+}
+
+// ObjectReferenceTypes are rules for the Object Reference for Salesforce and Lightning Platform
+// Fields are indexed from the field table of each object
+var ObjectReferenceTypes = []SupportedType{
+	SupportedType{
+		TypeName: "Guide",
+		ID:       "sforce_api_objects_list",
+		NoTrim:   true,
+	},
+	SupportedType{
+		TypeName:        "Object",
+		IDPrefix:        "sforce_api_objects_",
+		NoTrim:          true,
+		ParseContent:    true,
+		ContentTypeName: "Field",
+	},
+	SupportedType{
+		TypeName: "Guide",
+		IDPrefix: "sforce_api_concepts",
+		NoTrim:   true,
+	},
+}
+
+// MetadataAPITypes are rules for the Metadata API Developer Guide
+var MetadataAPITypes = []SupportedType{
+	SupportedType{
+		TypeName:    "Type",
+		ID:          "meta_types_list",
+		IsContainer: true,
+		CascadeType: true,
+	},
+	SupportedType{
+		TypeName:    "Function",
+		ID:          "meta_calls_intro",
+		IsContainer: true,
+		CascadeType: true,
+	},
+	SupportedType{
+		TypeName:    "Guide",
+		IDPrefix:    "meta_intro",
+		CascadeType: true,
+	},
+}
+
+// LWCTypes are rules for the Lightning Web Components Developer Guide
+// Base components and their attributes are documented in the Component Library, which is not an
+// Atlas deliverable, so only the modules documented in the guide are indexed
+var LWCTypes = []SupportedType{
+	SupportedType{
+		TypeName:    "Module",
+		TitlePrefix: "lightning/",
+		NoTrim:      true,
+	},
+	SupportedType{
+		TypeName:    "Module",
+		TitlePrefix: "@salesforce/",
+		NoTrim:      true,
+	},
+	// Everything else in the guide
+	SupportedType{
+		TypeName: "Guide",
+		MatchAll: true,
+		NoTrim:   true,
+	},
+}
+
+// CLITypes are rules for the Salesforce CLI Command Reference
+// Options are indexed from the flags documented on each command
+var CLITypes = []SupportedType{
+	SupportedType{
+		TypeName: "Guide",
+		ID:       "cli_reference_unified",
+		NoTrim:   true,
+	},
+	// Topic pages list the commands under them
+	SupportedType{
+		TypeName:        "Command",
+		TitleSuffix:     "Commands",
+		IsContainer:     true,
+		CascadeType:     true,
+		ParseContent:    true,
+		ContentTypeName: "Option",
+	},
+	SupportedType{
+		TypeName:        "Command",
+		IDPrefix:        "cli_reference_",
+		NoTrim:          true,
+		ParseContent:    true,
+		ContentTypeName: "Option",
+	},
+}
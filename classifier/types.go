@@ -0,0 +1,134 @@
+// Package classifier finds the docset type of each entry in an Atlas TOC
+package classifier
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/vividboarder/docset-sfdc/atlas"
+)
+
+// SupportedType contains information for generating indexes for types we care about
+type SupportedType struct {
+	// Exact match against an id
+	ID string
+	// Match against a prefix for the id
+	IDPrefix string
+	// Match against a prefix for the title
+	TitlePrefix string
+	// Match against a suffix for the title
+	TitleSuffix string
+	// Match every entry. Used as a fallback at the end of a list of rules
+	MatchAll bool
+	// Override Title
+	TitleOverride string
+	// Docset type
+	TypeName string
+	// Not sure...
+	AppendParents bool
+	// Skip trimming of suffix from title
+	NoTrim bool
+	// Parse the content for entries of ContentTypeName. Eg. Fields of an Object
+	ParseContent bool
+	// Docset type of entries found when parsing the content
+	ContentTypeName string
+	// Should this name be pushed int othe path for child entries Eg. Class name prefix methods
+	PushName bool
+	// Should a namspace be prefixed to the database entry
+	ShowNamespace bool
+	// Indicates that this just contains other nodes and we don't want to index this node
+	// This type will cascade down one level, but IsContainer itself is not hereditary
+	IsContainer bool
+	// Indicates that this and all nodes underneith should be hidden
+	// This is hereditary, even for nodes that have their own type
+	IsHidden bool
+	// Should cascade type downwards unless the child has it's own type
+	CascadeType bool
+	// Should cascade type downwards, even if children have their own type
+	ForceCascadeType bool
+}
+
+// TypeNotFoundError is returned for a TOCEntry with an unknown type
+type TypeNotFoundError struct {
+	Entry atlas.TOCEntry
+}
+
+func (err TypeNotFoundError) Error() string {
+	return fmt.Sprintf("Type not found: %s %s", err.Entry.Text, err.Entry.ID)
+}
+
+// matchesTitle returns true if the title matches that of the specified type
+func (suppType SupportedType) matchesTitle(title string) bool {
+	match := false
+	match = match || (suppType.TitlePrefix != "" &&
+		strings.HasPrefix(title, suppType.TitlePrefix))
+	match = match || (suppType.TitleSuffix != "" &&
+		strings.HasSuffix(title, suppType.TitleSuffix))
+	return match
+}
+
+// matchesID returns true if the ID matches that of the specified type
+func (suppType SupportedType) matchesID(id string) bool {
+	if suppType.ID != "" && suppType.ID == id {
+		return true
+	}
+	if suppType.IDPrefix != "" {
+		return strings.HasPrefix(id, suppType.IDPrefix)
+	}
+	return false
+}
+
+// Matches indicates that the TOCEntry is of this SupportedType
+// This is done by checking the title and id of the entry
+func (suppType SupportedType) Matches(entry atlas.TOCEntry) bool {
+	return suppType.MatchAll || suppType.matchesTitle(entry.Text) || suppType.matchesID(entry.ID)
+}
+
+// CleanTitle trims known suffix from TOCEntry titles
+func (suppType SupportedType) CleanTitle(entry atlas.TOCEntry) string {
+	if suppType.TitleOverride != "" {
+		return suppType.TitleOverride
+	}
+	if suppType.NoTrim {
+		return entry.Text
+	}
+	return strings.TrimSuffix(entry.Text, " "+suppType.TitleSuffix)
+}
+
+// EntryName returns the name to index an entry by
+// Types that show a namespace are prefixed with the closest name in the breadcrumb
+func (suppType SupportedType) EntryName(entry atlas.TOCEntry, breadcrumb Breadcrumb) string {
+	name := suppType.CleanTitle(entry)
+	if suppType.ShowNamespace && len(breadcrumb) > 0 {
+		// Show namespace for methods
+		name = breadcrumb[len(breadcrumb)-1] + "." + name
+	}
+	return name
+}
+
+// ShouldCascade returns if this type should be cascaded down to the child
+func (suppType SupportedType) ShouldCascade() bool {
+	return suppType.ForceCascadeType || suppType.CascadeType || suppType.IsContainer
+}
+
+// CreateChildType returns a child type inheriting the current type
+func (suppType SupportedType) CreateChildType() SupportedType {
+	// Reset values that do not cascade
+	suppType.IsContainer = false
+	return suppType
+}
+
+// ShouldParseContent returns if entries should be indexed from the content of this type
+func (suppType SupportedType) ShouldParseContent() bool {
+	return suppType.ParseContent && suppType.ContentTypeName != ""
+}
+
+// ShouldSkipIndex returns if entries of this type should be left out of the index
+func (suppType SupportedType) ShouldSkipIndex() bool {
+	return suppType.IsContainer || suppType.IsHidden
+}
+
+// IsValidType returns whether or not this is a valid type
+func (suppType SupportedType) IsValidType() bool {
+	return suppType.TypeName != ""
+}
@@ -0,0 +1,86 @@
+package classifier
+
+import (
+	"github.com/vividboarder/docset-sfdc/atlas"
+	"github.com/vividboarder/docset-sfdc/logging"
+)
+
+// Breadcrumb holds the names pushed by the parents of an entry. Eg. the class of a method
+type Breadcrumb []string
+
+// Push returns a Breadcrumb with a name added, leaving the original unchanged
+// so siblings and concurrent walks never share a backing array
+func (breadcrumb Breadcrumb) Push(name string) Breadcrumb {
+	return append(breadcrumb[:len(breadcrumb):len(breadcrumb)], name)
+}
+
+// Visitor is called for each entry with a page while walking a TOC
+// err is set if no type could be found for the entry
+type Visitor func(entry atlas.TOCEntry, entryType SupportedType, breadcrumb Breadcrumb, err error)
+
+// Walker iterates through a TOC, finding the type of each entry using a list of rules
+// The breadcrumb is passed to each visit, so a Walker can be shared between goroutines
+type Walker struct {
+	// Rules are matched in order, so more specific rules should come first
+	Rules []SupportedType
+}
+
+// NewWalker returns a Walker matching entries against a list of rules
+func NewWalker(rules []SupportedType) *Walker {
+	return &Walker{Rules: rules}
+}
+
+// GetEntryType will return an entry type that should be used for a given entry and it's parent's type
+func (walker *Walker) GetEntryType(entry atlas.TOCEntry, parentType SupportedType) (SupportedType, error) {
+	// Hidden is hereditary, even if the child has it's own type
+	if parentType.ForceCascadeType || parentType.IsHidden {
+		return parentType.CreateChildType(), nil
+	}
+
+	childType, err := walker.LookupEntryType(entry)
+	if err != nil && parentType.ShouldCascade() {
+		childType = parentType.CreateChildType()
+		err = nil
+	}
+
+	return childType, err
+}
+
+// LookupEntryType returns the first matching SupportedType for a given entry or returns an error
+func (walker *Walker) LookupEntryType(entry atlas.TOCEntry) (SupportedType, error) {
+	for _, t := range walker.Rules {
+		if t.Matches(entry) {
+			return t, nil
+		}
+	}
+	return SupportedType{}, TypeNotFoundError{Entry: entry}
+}
+
+// WalkEntries iterates through all child toc items, cascading types, and visits those with a page
+// The breadcrumb is that of the entry, so a subtree can be walked on it's own
+func (walker *Walker) WalkEntries(entry atlas.TOCEntry, entryType SupportedType, breadcrumb Breadcrumb, visit Visitor) {
+	if entryType.PushName {
+		breadcrumb = breadcrumb.Push(entryType.CleanTitle(entry))
+	}
+
+	for _, child := range entry.Children {
+		logging.Debug("Reading child: %s", child.Text)
+		// Entries without an HTML page still get a type so it can cascade to their children
+		childType, err := walker.GetEntryType(child, entryType)
+		if child.LinkAttr.Href == "" {
+			logging.Debug("%s has no link. Skipping", child.Text)
+		} else {
+			visit(child, childType, breadcrumb, err)
+		}
+		if len(child.Children) > 0 {
+			walker.WalkEntries(child, childType, breadcrumb, visit)
+		}
+	}
+	logging.Debug("Done processing children for %s", entry.Text)
+}
+
+// Walk visits every entry of a TOC, starting from a root without a type
+func (walker *Walker) Walk(toc *atlas.AtlasTOC, visit Visitor) {
+	root := atlas.TOCEntry{Text: toc.DocTitle, Children: toc.TOCEntries}
+	walker.WalkEntries(root, SupportedType{}, nil, visit)
+}
@@ -0,0 +1,58 @@
+package classifier
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/vividboarder/docset-sfdc/atlas"
+)
+
+// newEntry returns a TOC entry with a page
+func newEntry(text string, children ...atlas.TOCEntry) atlas.TOCEntry {
+	return atlas.TOCEntry{Text: text, LinkAttr: atlas.LinkAttr{Href: text + ".htm"}, Children: children}
+}
+
+// TestWalkConcurrently names methods from the breadcrumb while several walks share a Walker
+func TestWalkConcurrently(t *testing.T) {
+	toc := &atlas.AtlasTOC{
+		Deliverable: "apexcode",
+		TOCEntries: []atlas.TOCEntry{
+			newEntry("System Namespace",
+				newEntry("String Class",
+					newEntry("String Methods", newEntry("contains(substring)")),
+				),
+				newEntry("Database Class",
+					newEntry("Database Methods", newEntry("insert(record)")),
+				),
+			),
+		},
+	}
+	expected := map[string]bool{
+		"String.contains(substring)": true,
+		"Database.insert(record)":    true,
+	}
+
+	walker := NewWalker(SupportedTypes)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			found := 0
+			walker.Walk(toc, func(entry atlas.TOCEntry, entryType SupportedType, breadcrumb Breadcrumb, err error) {
+				if err != nil || entryType.TypeName != "Method" || entryType.ShouldSkipIndex() {
+					return
+				}
+				name := entryType.EntryName(entry, breadcrumb)
+				if !expected[name] {
+					t.Errorf("Unexpected method name %s", name)
+				}
+				found++
+			})
+			if found != len(expected) {
+				t.Errorf("Found %d methods, expected %d", found, len(expected))
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -1,4 +1,4 @@
-module github.com/vividboarder/docset-sfdc/SFDashC
+module github.com/vividboarder/docset-sfdc
 
 go 1.14
 
@@ -8,5 +8,5 @@ require (
 	github.com/lib/pq v1.7.0 // indirect
 	github.com/mattn/go-sqlite3 v1.2.1-0.20170407154627-cf7286f069c3
 	github.com/ziutek/mymysql v1.5.4 // indirect
-	golang.org/x/net v0.0.0-20170503120255-feeb485667d1 // indirect
+	golang.org/x/net v0.0.0-20170503120255-feeb485667d1
 )
@@ -0,0 +1,105 @@
+// Package indexer reads and writes the sqlite search index used by Dash
+package indexer
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+
+	"github.com/coopernurse/gorp"
+	// Registers the sqlite3 driver
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/vividboarder/docset-sfdc/logging"
+)
+
+// DBName is the name of the index inside of a docset
+const DBName = "docSet.dsidx"
+
+// Sqlite Struct
+// SearchIndex is the database table that indexes the docs
+type SearchIndex struct {
+	ID   int64  `db:"id" json:"-"`
+	Name string `db:"name" json:"name"`
+	Type string `db:"type" json:"type"`
+	Path string `db:"path" json:"path"`
+}
+
+// Index is an open search index that entries can be added to
+type Index struct {
+	dbmap *gorp.DbMap
+}
+
+// newDbMap maps the searchIndex table for a db
+func newDbMap(db *sql.DB) *gorp.DbMap {
+	dbmap := &gorp.DbMap{Db: db, Dialect: gorp.SqliteDialect{}}
+	dbmap.AddTableWithName(SearchIndex{}, "searchIndex").SetKeys(true, "ID")
+	return dbmap
+}
+
+// Create will initialize a new instance of a sqlite db for indexing
+// Any entries in an existing index are removed
+func Create(dbPath string) (*Index, error) {
+	err := os.MkdirAll(filepath.Dir(dbPath), 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	dbmap := newDbMap(db)
+
+	err = dbmap.CreateTablesIfNotExists()
+	if err == nil {
+		err = dbmap.TruncateTables()
+	}
+	if err != nil {
+		logging.WarnIfError(db.Close())
+		return nil, err
+	}
+
+	return &Index{dbmap: dbmap}, nil
+}
+
+// Add will index an entry into the sqlite3 database
+func (index *Index) Add(name string, typeName string, path string) error {
+	si := SearchIndex{
+		Name: name,
+		Type: typeName,
+		Path: path,
+	}
+
+	err := index.dbmap.Insert(&si)
+	if err != nil {
+		return err
+	}
+
+	logging.Debug("%s is indexed as a %s", name, typeName)
+	return nil
+}
+
+// Close closes the underlying database
+func (index *Index) Close() error {
+	return index.dbmap.Db.Close()
+}
+
+// Read returns every entry from an existing index
+func Read(dbPath string) (rows []SearchIndex, err error) {
+	// Opening would otherwise create a new empty index
+	if _, err = os.Stat(dbPath); err != nil {
+		return
+	}
+
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		return
+	}
+	defer func() {
+		logging.WarnIfError(db.Close())
+	}()
+
+	_, err = newDbMap(db).Select(&rows, "SELECT * FROM searchIndex ORDER BY id")
+	return
+}
@@ -1,7 +1,9 @@
-package main
+// Package logging prints leveled messages shared by all SFDashC packages
+package logging
 
 import (
 	"fmt"
+	"io"
 	"log"
 )
 
@@ -39,11 +41,21 @@ func getLogPrefix() string {
 	return fmt.Sprintf("%s: %s:", prefix, getLevelText())
 }
 
-// SetLogLevel will set the maximum level to print
-func SetLogLevel(level int) {
+// SetLevel will set the maximum level to print
+func SetLevel(level int) {
 	logLevel = level
 }
 
+// Writer returns the writer messages are printed to
+func Writer() io.Writer {
+	return log.Writer()
+}
+
+// SetOutput sets the writer messages are printed to
+func SetOutput(w io.Writer) {
+	log.SetOutput(w)
+}
+
 // Log will print a formatted message with a prefix for a specified level
 // If the level is greater than the maximum log level, it will not print
 func Log(level int, format string, a ...interface{}) {
@@ -54,27 +66,34 @@ func Log(level int, format string, a ...interface{}) {
 	}
 }
 
-// LogError will print an error message
+// Error will print an error message
 // If the level is greater than the maximum log level, it will not print
 // It is recommended to use log.Fatal() instead since it will handle exits for you
-func LogError(format string, a ...interface{}) {
+func Error(format string, a ...interface{}) {
 	Log(ERROR, format, a...)
 }
 
-// LogInfo will print a warning message
+// Warning will print a warning message
 // If the level is greater than the maximum log level, it will not print
-func LogWarning(format string, a ...interface{}) {
+func Warning(format string, a ...interface{}) {
 	Log(WARNING, format, a...)
 }
 
-// LogInfo will print an info message
+// Info will print an info message
 // If the level is greater than the maximum log level, it will not print
-func LogInfo(format string, a ...interface{}) {
+func Info(format string, a ...interface{}) {
 	Log(INFO, format, a...)
 }
 
-// LogDebug will print an debug message
+// Debug will print an debug message
 // If the level is greater than the maximum log level, it will not print
-func LogDebug(format string, a ...interface{}) {
+func Debug(format string, a ...interface{}) {
 	Log(DEBUG, format, a...)
 }
+
+// WarnIfError prints a warning if an error is not nil
+func WarnIfError(err error) {
+	if err != nil {
+		Warning(err.Error())
+	}
+}
@@ -0,0 +1,328 @@
+package packager
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/vividboarder/docset-sfdc/atlas"
+	"github.com/vividboarder/docset-sfdc/logging"
+	"github.com/vividboarder/docset-sfdc/registry"
+)
+
+// archiveDir is where archives are written, relative to the output dir
+const archiveDir = "archive"
+
+// ArchiveDir returns the path to the archive dir in the output dir
+func (packager *Packager) ArchiveDir() string {
+	return filepath.Join(packager.OutDir, archiveDir)
+}
+
+// VersionsDir holds a directory for each version of an archive or build
+const VersionsDir = "versions"
+
+// archiveModTime is used for every file in an archive so they are reproducible
+var archiveModTime = time.Unix(0, 0)
+
+// DocsetFeed is the docset.json used by Dash User Contributions
+type DocsetFeed struct {
+	Name             string            `json:"name"`
+	Version          string            `json:"version"`
+	Archive          string            `json:"archive"`
+	Author           FeedAuthor        `json:"author"`
+	Aliases          []string          `json:"aliases"`
+	SpecificVersions []SpecificVersion `json:"specific_versions"`
+}
+
+// FeedAuthor is the author of a docset
+type FeedAuthor struct {
+	Name string `json:"name"`
+	Link string `json:"link"`
+}
+
+// SpecificVersion is an older version of a docset that can still be installed
+type SpecificVersion struct {
+	Version string `json:"version"`
+	Archive string `json:"archive"`
+}
+
+var feedAuthor = FeedAuthor{
+	Name: "ViViDboarder",
+	Link: "https://github.com/ViViDboarder",
+}
+
+// getArchiveName returns the base name used for the archive of a docset
+func getArchiveName(toc *atlas.AtlasTOC) string {
+	name := strings.Replace("Salesforce "+registry.GetDisplayName(toc), " ", "_", -1)
+	if toc.Locale != atlas.DefaultLocale {
+		name += "_" + toc.Locale
+	}
+	return name
+}
+
+// ArchiveDocset creates an archive, feed and readme for a packaged deliverable
+func (packager *Packager) ArchiveDocset(locale string, deliverable string, version string) error {
+	toc, err := atlas.LoadTOC(packager.BuildDir, locale, deliverable, version)
+	if err != nil {
+		return fmt.Errorf("Cannot archive %s. Could not read TOC: %s", deliverable, err.Error())
+	}
+
+	docsetPath := packager.getDocsetPath(toc)
+	if _, err = os.Stat(docsetPath); os.IsNotExist(err) {
+		return fmt.Errorf("Cannot archive %s. Missing: %s", deliverable, docsetPath)
+	}
+
+	name := getArchiveName(toc)
+	docsetArchiveDir := filepath.Join(packager.ArchiveDir(), name)
+	err = os.MkdirAll(docsetArchiveDir, 0755)
+	if err != nil {
+		return err
+	}
+
+	// Pinned versions are only archived as a specific version
+	if toc.VersionPinned {
+		archivePath := filepath.Join(docsetArchiveDir, getVersionArchivePath(toc.Version.DocVersion, name))
+		err = os.MkdirAll(filepath.Dir(archivePath), 0755)
+		if err != nil {
+			return err
+		}
+		err = writeArchive(docsetPath, archivePath)
+		if err != nil {
+			return err
+		}
+		err = packager.updateSpecificVersions(docsetArchiveDir, name)
+		if err != nil {
+			return err
+		}
+		logging.Info("Finished archive %s", archivePath)
+		return nil
+	}
+
+	// Generated tgz archive
+	archivePath := filepath.Join(docsetArchiveDir, name+".tgz")
+	err = writeArchive(docsetPath, archivePath)
+	if err != nil {
+		return err
+	}
+
+	// Keep a copy for this version
+	if packager.KeepVersions {
+		versionPath := filepath.Join(docsetArchiveDir, getVersionArchivePath(toc.Version.DocVersion, name))
+		err = CopyFile(archivePath, versionPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Generate docset.json
+	err = packager.saveDocsetFeed(toc, docsetArchiveDir)
+	if err != nil {
+		return err
+	}
+
+	// Copy icons
+	icon := registry.Get(deliverable).Icon
+	err = CopyFile(filepath.Join(packager.ResourcesDir, icon+".png"), filepath.Join(docsetArchiveDir, "icon.png"))
+	if err != nil {
+		return err
+	}
+	err = CopyFile(filepath.Join(packager.ResourcesDir, icon+"@2x.png"), filepath.Join(docsetArchiveDir, "icon@2x.png"))
+	if err != nil {
+		return err
+	}
+
+	// Copy readme
+	readme, err := ioutil.ReadFile(filepath.Join(packager.ResourcesDir, "Archive_Readme.md"))
+	if err != nil {
+		return err
+	}
+	readme = []byte(strings.Replace(string(readme), "DOCSET_NAME", registry.GetDisplayName(toc), -1))
+	err = ioutil.WriteFile(filepath.Join(docsetArchiveDir, "README.md"), readme, 0644)
+	if err != nil {
+		return err
+	}
+
+	logging.Info("Finished archive %s", archivePath)
+	return nil
+}
+
+// getVersionArchivePath returns the path of an archive for a specific version relative to the docset.json
+func getVersionArchivePath(version string, name string) string {
+	return filepath.ToSlash(filepath.Join(VersionsDir, version, name+".tgz"))
+}
+
+// findSpecificVersions returns all versioned archives for a docset in the archive and history dirs
+func (packager *Packager) findSpecificVersions(name string) (specificVersions []SpecificVersion, err error) {
+	searchDirs := []string{filepath.Join(packager.ArchiveDir(), name)}
+	if packager.ArchiveHistoryDir != "" {
+		searchDirs = append(searchDirs, filepath.Join(packager.ArchiveHistoryDir, name))
+	}
+
+	found := map[string]bool{}
+	for _, searchDir := range searchDirs {
+		versionDirs, globErr := filepath.Glob(filepath.Join(searchDir, VersionsDir, "*"))
+		if globErr != nil {
+			err = globErr
+			return
+		}
+		for _, versionDir := range versionDirs {
+			version := filepath.Base(versionDir)
+			archive := getVersionArchivePath(version, name)
+			if found[version] {
+				continue
+			}
+			if _, statErr := os.Stat(filepath.Join(searchDir, archive)); statErr != nil {
+				logging.Debug("No archive found in %s", versionDir)
+				continue
+			}
+			found[version] = true
+			specificVersions = append(specificVersions, SpecificVersion{
+				Version: version,
+				Archive: archive,
+			})
+		}
+	}
+
+	// Newest versions first
+	sort.Slice(specificVersions, func(i, j int) bool {
+		return atlas.CompareVersions(specificVersions[i].Version, specificVersions[j].Version) > 0
+	})
+	return
+}
+
+// saveDocsetFeed writes the docset.json with all versions that have been archived
+func (packager *Packager) saveDocsetFeed(toc *atlas.AtlasTOC, docsetArchiveDir string) error {
+	feedPath := filepath.Join(docsetArchiveDir, "docset.json")
+	name := getArchiveName(toc)
+	feed := DocsetFeed{
+		Name:             registry.GetDocsetName(toc),
+		Version:          toc.Version.DocVersion,
+		Archive:          name + ".tgz",
+		Author:           feedAuthor,
+		Aliases:          registry.Get(toc.Deliverable).Aliases,
+		SpecificVersions: []SpecificVersion{},
+	}
+
+	specificVersions, err := packager.findSpecificVersions(name)
+	if err != nil {
+		return err
+	}
+	if specificVersions != nil {
+		feed.SpecificVersions = specificVersions
+	}
+
+	contents, err := json.MarshalIndent(feed, "", "    ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(feedPath, append(contents, '\n'), 0644)
+}
+
+// updateSpecificVersions updates only the specific versions of an existing docset.json
+// If there is no docset.json yet, it will be written when the current version is archived
+func (packager *Packager) updateSpecificVersions(docsetArchiveDir string, name string) error {
+	feedPath := filepath.Join(docsetArchiveDir, "docset.json")
+	contents, err := ioutil.ReadFile(feedPath)
+	if os.IsNotExist(err) {
+		logging.Info("No %s yet. It will be written when the current version is archived", feedPath)
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	var feed DocsetFeed
+	err = json.Unmarshal(contents, &feed)
+	if err != nil {
+		return fmt.Errorf("Could not read %s: %s", feedPath, err.Error())
+	}
+
+	specificVersions, err := packager.findSpecificVersions(name)
+	if err != nil {
+		return err
+	}
+	if specificVersions != nil {
+		feed.SpecificVersions = specificVersions
+	}
+
+	contents, err = json.MarshalIndent(feed, "", "    ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(feedPath, append(contents, '\n'), 0644)
+}
+
+// writeArchive writes a gzipped tar of a directory that is identical for identical contents
+func writeArchive(sourceDir string, archivePath string) error {
+	ofile, err := os.Create(archivePath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		logging.WarnIfError(ofile.Close())
+	}()
+
+	gzipWriter := gzip.NewWriter(ofile)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	baseDir := filepath.Dir(sourceDir)
+	// Walk visits files in lexical order, so entries are always sorted
+	err = filepath.Walk(sourceDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Name() == ".DS_Store" {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(baseDir, path)
+		if err != nil {
+			return err
+		}
+
+		header := &tar.Header{
+			Name:    filepath.ToSlash(relPath),
+			ModTime: archiveModTime,
+			Mode:    0644,
+		}
+		if info.IsDir() {
+			header.Name += "/"
+			header.Typeflag = tar.TypeDir
+			header.Mode = 0755
+			return tarWriter.WriteHeader(header)
+		}
+
+		header.Typeflag = tar.TypeReg
+		header.Size = info.Size()
+		err = tarWriter.WriteHeader(header)
+		if err != nil {
+			return err
+		}
+
+		ifile, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			logging.WarnIfError(ifile.Close())
+		}()
+		_, err = io.Copy(tarWriter, ifile)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	err = tarWriter.Close()
+	if err != nil {
+		return err
+	}
+	return gzipWriter.Close()
+}
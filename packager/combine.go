@@ -0,0 +1,233 @@
+package packager
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/vividboarder/docset-sfdc/atlas"
+	"github.com/vividboarder/docset-sfdc/indexer"
+	"github.com/vividboarder/docset-sfdc/logging"
+	"github.com/vividboarder/docset-sfdc/registry"
+	"github.com/vividboarder/docset-sfdc/renderer"
+)
+
+// atlasLinkPattern matches links to a page of any Atlas deliverable, capturing the meta dir,
+// deliverable and page. Eg. https://developer.salesforce.com/docs/atlas.en-us.pages.meta/pages/pages_intro.htm
+var atlasLinkPattern = regexp.MustCompile(`(?:^|/)(atlas\.[^/]+\.meta)/([^/]+)/(.+)$`)
+
+// metaDirVersionPattern matches the version in the meta dir of a pinned version. Eg. atlas.en-us.252.0.apexcode.meta
+var metaDirVersionPattern = regexp.MustCompile(`^(atlas\.[^.]+)\.\d+\.\d+\.`)
+
+// atlasHost is the host of links to the online documentation
+const atlasHost = "developer.salesforce.com"
+
+// unversionedMetaDir returns the meta dir without a pinned version, so links to any version can be found
+func unversionedMetaDir(metaDir string) string {
+	return metaDirVersionPattern.ReplaceAllString(metaDir, "$1.")
+}
+
+// newCombinedTOC returns a TOC describing the combined docset for a set of TOCs
+func newCombinedTOC(tocs []*atlas.AtlasTOC) *atlas.AtlasTOC {
+	toc := &atlas.AtlasTOC{
+		Deliverable:   registry.CombinedDeliverable,
+		DocTitle:      "Salesforce " + registry.Get(registry.CombinedDeliverable).GetFriendlyName(),
+		Locale:        tocs[0].Locale,
+		Language:      tocs[0].Language,
+		Version:       tocs[0].Version,
+		VersionPinned: tocs[0].VersionPinned,
+	}
+	toc.Title = toc.DocTitle
+	return toc
+}
+
+// rewriteAtlasLink returns a local link for links to a page of a deliverable in the docset
+// Links may be absolute or relative to the root of the online documentation, and to any version of the page
+// metaDirs maps unversioned meta dirs of each deliverable in the docset to the meta dir it's pages are in
+// Every page has a base of the Documents dir, so the link is relative to that rather than the page
+func rewriteAtlasLink(link string, metaDirs map[string]string) (string, bool) {
+	linkURL, err := url.Parse(link)
+	if err != nil {
+		return link, false
+	}
+	// Other relative links already resolve from the base
+	isRootRelative := linkURL.Scheme == "" && linkURL.Host == "" && strings.HasPrefix(linkURL.Path, "/")
+	if linkURL.Host != atlasHost && !isRootRelative {
+		return link, false
+	}
+	match := atlasLinkPattern.FindStringSubmatch(linkURL.Path)
+	if match == nil {
+		return link, false
+	}
+	metaDir, ok := metaDirs[unversionedMetaDir(match[1])]
+	if !ok {
+		return link, false
+	}
+
+	target := path.Join(metaDir, match[2], match[3])
+	if linkURL.Fragment != "" {
+		target += "#" + linkURL.Fragment
+	}
+	return target, true
+}
+
+// rewriteAtlasLinks resolves links between deliverables in the docset to local pages
+func rewriteAtlasLinks(content string, metaDirs map[string]string) (string, error) {
+	return renderer.RewriteLinks(content, func(link string) string {
+		target, _ := rewriteAtlasLink(link, metaDirs)
+		return target
+	})
+}
+
+// copyCombinedPages copies all pages of a meta dir, resolving links to other deliverables locally
+func copyCombinedPages(source string, documentsDir string, metaDirs map[string]string) error {
+	return filepath.Walk(source, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(filepath.Dir(source), filePath)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(documentsDir, relPath)
+
+		if info.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		if ext := filepath.Ext(filePath); ext != ".htm" && ext != ".html" {
+			return CopyFile(filePath, target)
+		}
+
+		contents, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+		content, err := rewriteAtlasLinks(string(contents), metaDirs)
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(target, []byte(content), 0644)
+	})
+}
+
+// saveCombinedLandingPage writes a landing page with a section for each deliverable
+func (packager *Packager) saveCombinedLandingPage(combined *atlas.AtlasTOC, tocs []*atlas.AtlasTOC, filePath string) error {
+	ofile, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+
+	err = packager.Renderer.WriteCombinedLandingPage(ofile, combined, tocs)
+	if closeErr := ofile.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// saveCombinedIndex merges the index of each deliverable into a single index
+func (packager *Packager) saveCombinedIndex(tocs []*atlas.AtlasTOC, dbPath string) error {
+	combinedIndex, err := indexer.Create(dbPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		logging.WarnIfError(combinedIndex.Close())
+	}()
+
+	for _, toc := range tocs {
+		rows, err := indexer.Read(filepath.Join(packager.BuildDir, toc.IndexFilename()))
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			err = combinedIndex.Add(row.Name, row.Type, row.Path)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// PackageCombinedDocset assembles a single docset for several deliverables from the build dir
+func (packager *Packager) PackageCombinedDocset(locale string, deliverables []string, version string) error {
+	if len(deliverables) == 0 {
+		return errors.New("Cannot package a combined docset without deliverables")
+	}
+
+	var tocs []*atlas.AtlasTOC
+	metaDirs := map[string]string{}
+	for _, deliverable := range deliverables {
+		toc, err := atlas.LoadTOC(packager.BuildDir, locale, deliverable, version)
+		if err != nil {
+			return fmt.Errorf("Cannot package %s. Could not read TOC: %s", deliverable, err.Error())
+		}
+		// Check every input before copying anything
+		_, err = packager.getPackageFiles(toc)
+		if err != nil {
+			return err
+		}
+		tocs = append(tocs, toc)
+		metaDirs[unversionedMetaDir(toc.MetaDir())] = toc.MetaDir()
+	}
+
+	combined := newCombinedTOC(tocs)
+	docsetPath := packager.getDocsetPath(combined)
+	resourcesPath := filepath.Join(docsetPath, "Contents", "Resources")
+	documentsDir := filepath.Join(resourcesPath, "Documents")
+
+	// Start fresh so removed pages don't linger
+	err := os.RemoveAll(docsetPath)
+	if err != nil {
+		return err
+	}
+
+	for _, toc := range tocs {
+		logging.Debug("Copying %s into %s", toc.MetaDir(), docsetPath)
+		err = copyCombinedPages(filepath.Join(packager.BuildDir, toc.MetaDir()), documentsDir, metaDirs)
+		if err != nil {
+			return err
+		}
+	}
+
+	// All deliverables share the same stylesheets
+	err = CopyStylesheets(packager.BuildDir, documentsDir)
+	if err != nil {
+		return err
+	}
+
+	icon := registry.Get(registry.CombinedDeliverable).Icon
+	err = CopyFile(filepath.Join(packager.ResourcesDir, icon+".png"), filepath.Join(docsetPath, "icon.png"))
+	if err != nil {
+		return err
+	}
+	err = CopyFile(filepath.Join(packager.ResourcesDir, icon+"@2x.png"), filepath.Join(docsetPath, "icon@2x.png"))
+	if err != nil {
+		return err
+	}
+
+	err = packager.saveCombinedLandingPage(combined, tocs, filepath.Join(documentsDir, combined.IndexPage()))
+	if err != nil {
+		return err
+	}
+
+	err = packager.saveCombinedIndex(tocs, filepath.Join(resourcesPath, indexer.DBName))
+	if err != nil {
+		return err
+	}
+
+	err = packager.savePlist(combined, filepath.Join(docsetPath, "Contents", "Info.plist"))
+	if err != nil {
+		return err
+	}
+
+	logging.Info("Finished building %s with %s", docsetPath, strings.Join(deliverables, ", "))
+	return nil
+}
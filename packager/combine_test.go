@@ -0,0 +1,80 @@
+package packager
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/vividboarder/docset-sfdc/renderer"
+)
+
+// TestRewriteAtlasLinks checks that links between deliverables resolve to local pages from the page base
+func TestRewriteAtlasLinks(t *testing.T) {
+	// Visualforce is pinned to a version, so it's pages are in a versioned meta dir
+	metaDirs := map[string]string{
+		"atlas.en-us.apexcode.meta": "atlas.en-us.apexcode.meta",
+		"atlas.en-us.pages.meta":    "atlas.en-us.250.0.pages.meta",
+	}
+	page := "atlas.en-us.apexcode.meta/apexcode/apex_intro.htm"
+	content := renderer.New().PageHeader() + `<p>
+<a href="https://developer.salesforce.com/docs/atlas.en-us.pages.meta/pages/pages_intro.htm#setup">Visualforce</a>
+<a href="atlas.en-us.apexcode.meta/apexcode/apex_dml.htm">DML</a>
+<a href="https://developer.salesforce.com/docs/atlas.en-us.api_rest.meta/api_rest/intro_rest.htm">REST</a>
+<a href="https://example.com/docs/atlas.en-us.pages.meta/pages/pages_intro.htm">Elsewhere</a>
+<a href="/docs/atlas.en-us.apexcode.meta/apexcode/apex_classes.htm">Root relative</a>
+<a href="https://developer.salesforce.com/docs/atlas.en-us.252.0.pages.meta/pages/pages_compref.htm">Versioned</a>
+<a href="/docs/atlas.en-us.252.0.apexcode.meta/apexcode/apex_methods.htm#list">Root relative and versioned</a>
+</p>
+<pre>&lt;a href="https://developer.salesforce.com/docs/atlas.en-us.pages.meta/pages/pages_code.htm"&gt;</pre>`
+
+	rewritten, err := rewriteAtlasLinks(content, metaDirs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only links are rewritten, not text that looks like one
+	if !strings.Contains(rewritten, "https://developer.salesforce.com/docs/atlas.en-us.pages.meta/pages/pages_code.htm") {
+		t.Errorf("Text in a code sample was rewritten: %s", rewritten)
+	}
+
+	// Resolve every link the way a browser would from the page in the Documents dir
+	pageURL, err := url.Parse("file:///Documents/" + page)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var base *url.URL
+	var links []string
+	for _, match := range regexp.MustCompile(`<(base|a) href="([^"]*)"`).FindAllStringSubmatch(rewritten, -1) {
+		link, err := url.Parse(match[2])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if match[1] == "base" {
+			base = pageURL.ResolveReference(link)
+			continue
+		}
+		if base == nil {
+			t.Fatal("Links must come after the base")
+		}
+		links = append(links, base.ResolveReference(link).String())
+	}
+
+	expected := []string{
+		"file:///Documents/atlas.en-us.250.0.pages.meta/pages/pages_intro.htm#setup",
+		"file:///Documents/atlas.en-us.apexcode.meta/apexcode/apex_dml.htm",
+		"https://developer.salesforce.com/docs/atlas.en-us.api_rest.meta/api_rest/intro_rest.htm",
+		"https://example.com/docs/atlas.en-us.pages.meta/pages/pages_intro.htm",
+		"file:///Documents/atlas.en-us.apexcode.meta/apexcode/apex_classes.htm",
+		"file:///Documents/atlas.en-us.250.0.pages.meta/pages/pages_compref.htm",
+		"file:///Documents/atlas.en-us.apexcode.meta/apexcode/apex_methods.htm#list",
+	}
+	if len(links) != len(expected) {
+		t.Fatalf("Found links %v, expected %v", links, expected)
+	}
+	for i, link := range links {
+		if link != expected[i] {
+			t.Errorf("Link resolved to %s, expected %s", link, expected[i])
+		}
+	}
+}
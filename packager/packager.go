@@ -0,0 +1,259 @@
+// Package packager assembles docsets and archives from a build dir
+package packager
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/vividboarder/docset-sfdc/atlas"
+	"github.com/vividboarder/docset-sfdc/indexer"
+	"github.com/vividboarder/docset-sfdc/logging"
+	"github.com/vividboarder/docset-sfdc/registry"
+	"github.com/vividboarder/docset-sfdc/renderer"
+)
+
+// Formats that deliverables can be packaged as
+const DocsetFormat = "docset"
+const CombinedFormat = "combined"
+
+// Packager holds the settings used to package and archive deliverables
+type Packager struct {
+	// BuildDir is where deliverables were downloaded, rendered and indexed
+	BuildDir string
+	// OutDir is where docsets, archives and reports are written
+	OutDir string
+	// ResourcesDir contains the icons and readme used for packaging and archiving
+	ResourcesDir string
+	// Formats are the formats used when packaging
+	Formats []string
+	// PlistOverrides take precedence over defaults for every deliverable
+	PlistOverrides PlistOverrides
+	// KeepVersions enables keeping a copy of each archive in a versions directory
+	KeepVersions bool
+	// ArchiveHistoryDir is a directory of previously published docsets, such as the docsets
+	// directory of Dash User Contributions, whose versions are included in specific_versions
+	ArchiveHistoryDir string
+	// Renderer is used to write the landing page of combined docsets
+	Renderer *renderer.Renderer
+}
+
+// New returns a Packager for a build dir that packages docsets into an output dir
+func New(buildDir string, outDir string) *Packager {
+	return &Packager{
+		BuildDir:     buildDir,
+		OutDir:       outDir,
+		ResourcesDir: "resources",
+		Formats:      []string{DocsetFormat},
+		KeepVersions: true,
+		Renderer:     renderer.New(),
+	}
+}
+
+// getDocsetPath returns the path to the packaged docset for a deliverable
+func (packager *Packager) getDocsetPath(toc *atlas.AtlasTOC) string {
+	name := registry.GetDocsetName(toc)
+	if toc.VersionPinned {
+		return filepath.Join(packager.OutDir, fmt.Sprintf("%s %s.docset", name, toc.Version.DocVersion))
+	}
+	return filepath.Join(packager.OutDir, name+".docset")
+}
+
+// packageFile is a single file or directory to copy into the docset
+type packageFile struct {
+	Source string
+	Dest   string
+}
+
+// getPackageFiles returns everything that needs to be copied into the docset
+func (packager *Packager) getPackageFiles(toc *atlas.AtlasTOC) (files []packageFile, err error) {
+	deliverable := toc.Deliverable
+	docsetPath := packager.getDocsetPath(toc)
+	documentsDir := filepath.Join(docsetPath, "Contents", "Resources", "Documents")
+	icon := registry.Get(deliverable).Icon
+	metaDir := toc.MetaDir()
+	indexPage := toc.IndexPage()
+
+	files = []packageFile{
+		// All meta HTML
+		{filepath.Join(packager.BuildDir, metaDir), filepath.Join(documentsDir, metaDir)},
+		// Landing page
+		{filepath.Join(packager.BuildDir, indexPage), filepath.Join(documentsDir, indexPage)},
+		// Index
+		{filepath.Join(packager.BuildDir, toc.IndexFilename()), filepath.Join(docsetPath, "Contents", "Resources", indexer.DBName)},
+		// Icons
+		{filepath.Join(packager.ResourcesDir, icon+".png"), filepath.Join(docsetPath, "icon.png")},
+		{filepath.Join(packager.ResourcesDir, icon+"@2x.png"), filepath.Join(docsetPath, "icon@2x.png")},
+	}
+
+	// Fail before copying anything if an input is missing
+	var missing []string
+	for _, file := range files {
+		if _, statErr := os.Stat(file.Source); os.IsNotExist(statErr) {
+			missing = append(missing, file.Source)
+		}
+	}
+
+	// The Salesforce CSS must be downloaded, but local stylesheets depend on the render settings
+	for _, cssFile := range renderer.CSSFiles {
+		cssPath := filepath.Join(packager.BuildDir, cssFile)
+		if _, statErr := os.Stat(cssPath); os.IsNotExist(statErr) {
+			missing = append(missing, cssPath)
+		}
+	}
+
+	// All CSS
+	cssPaths, err := filepath.Glob(filepath.Join(packager.BuildDir, "*.css"))
+	if err != nil {
+		return
+	}
+	for _, cssPath := range cssPaths {
+		files = append(files, packageFile{cssPath, filepath.Join(documentsDir, filepath.Base(cssPath))})
+	}
+
+	if len(missing) > 0 {
+		err = fmt.Errorf(
+			"Cannot package %s. Missing: %s",
+			deliverable,
+			strings.Join(missing, ", "),
+		)
+	}
+	return
+}
+
+// PackageDocset assembles a docset for a deliverable from the build dir
+func (packager *Packager) PackageDocset(locale string, deliverable string, version string) error {
+	toc, err := atlas.LoadTOC(packager.BuildDir, locale, deliverable, version)
+	if err != nil {
+		return fmt.Errorf("Cannot package %s. Could not read TOC: %s", deliverable, err.Error())
+	}
+
+	files, err := packager.getPackageFiles(toc)
+	if err != nil {
+		return err
+	}
+
+	docsetPath := packager.getDocsetPath(toc)
+	// Start fresh so removed pages don't linger
+	err = os.RemoveAll(docsetPath)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		logging.Debug("Copying %s to %s", file.Source, file.Dest)
+		err = CopyPath(file.Source, file.Dest)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = packager.savePlist(toc, filepath.Join(docsetPath, "Contents", "Info.plist"))
+	if err != nil {
+		return err
+	}
+
+	logging.Info("Finished building %s", docsetPath)
+	return nil
+}
+
+// Package packages the deliverables in every format
+func (packager *Packager) Package(locale string, deliverables []string, version string) error {
+	for _, format := range packager.Formats {
+		switch format {
+		case DocsetFormat:
+			for _, deliverable := range deliverables {
+				err := packager.PackageDocset(locale, deliverable, version)
+				if err != nil {
+					return err
+				}
+			}
+		case CombinedFormat:
+			err := packager.PackageCombinedDocset(locale, deliverables, version)
+			if err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("Unknown package format %s", format)
+		}
+	}
+	return nil
+}
+
+// savePlist writes the generated Info.plist for a deliverable
+func (packager *Packager) savePlist(toc *atlas.AtlasTOC, filePath string) error {
+	ofile, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+
+	err = packager.writePlist(ofile, toc)
+	if closeErr := ofile.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// CopyPath copies a file or recursively copies a directory
+func CopyPath(source string, dest string) error {
+	return filepath.Walk(source, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(source, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dest, relPath)
+
+		if info.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		return CopyFile(path, target)
+	})
+}
+
+// CopyFile copies a single file, creating any parent directories
+func CopyFile(source string, dest string) error {
+	err := os.MkdirAll(filepath.Dir(dest), 0755)
+	if err != nil {
+		return err
+	}
+
+	ifile, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		logging.WarnIfError(ifile.Close())
+	}()
+
+	ofile, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(ofile, ifile)
+	if closeErr := ofile.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// CopyStylesheets copies all stylesheets from one build dir to another
+func CopyStylesheets(sourceDir string, destDir string) error {
+	cssPaths, err := filepath.Glob(filepath.Join(sourceDir, "*.css"))
+	if err != nil {
+		return err
+	}
+	for _, cssPath := range cssPaths {
+		err = CopyFile(cssPath, filepath.Join(destDir, filepath.Base(cssPath)))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
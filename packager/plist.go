@@ -0,0 +1,101 @@
+package packager
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/vividboarder/docset-sfdc/atlas"
+	"github.com/vividboarder/docset-sfdc/registry"
+)
+
+// DefaultFallbackURL is opened for pages that are not in the docset
+const DefaultFallbackURL = "https://developer.salesforce.com/docs/"
+
+// PlistOverrides replace the registered Info.plist settings of every deliverable
+// Empty strings and nil flags keep the registered values, so flags can turn settings off as well as on
+type PlistOverrides struct {
+	PlatformFamily   string
+	FallbackURL      string
+	IndexPage        string
+	EnableJavaScript *bool
+	FullTextSearch   *bool
+}
+
+// getPlistSettings returns settings for a deliverable with any overrides applied
+func (packager *Packager) getPlistSettings(toc *atlas.AtlasTOC) registry.PlistSettings {
+	settings := registry.Get(toc.Deliverable).Plist
+	if settings.FallbackURL == "" {
+		settings.FallbackURL = DefaultFallbackURL
+	}
+	if settings.IndexPage == "" {
+		settings.IndexPage = toc.IndexPage()
+	}
+
+	if packager.PlistOverrides.PlatformFamily != "" {
+		settings.PlatformFamily = packager.PlistOverrides.PlatformFamily
+	}
+	if packager.PlistOverrides.FallbackURL != "" {
+		settings.FallbackURL = packager.PlistOverrides.FallbackURL
+	}
+	if packager.PlistOverrides.IndexPage != "" {
+		settings.IndexPage = packager.PlistOverrides.IndexPage
+	}
+	if packager.PlistOverrides.EnableJavaScript != nil {
+		settings.EnableJavaScript = *packager.PlistOverrides.EnableJavaScript
+	}
+	if packager.PlistOverrides.FullTextSearch != nil {
+		settings.FullTextSearch = *packager.PlistOverrides.FullTextSearch
+	}
+	return settings
+}
+
+// getBundleIdentifier returns a unique identifier for a deliverable, locale and version
+func getBundleIdentifier(toc *atlas.AtlasTOC) string {
+	return strings.Join([]string{"salesforce", toc.Deliverable, toc.Locale, toc.Version.DocVersion}, ".")
+}
+
+var plistTemplate = template.Must(template.New("plist").Funcs(template.FuncMap{
+	"xml": func(value string) (string, error) {
+		var buf bytes.Buffer
+		err := xml.EscapeText(&buf, []byte(value))
+		return buf.String(), err
+	},
+}).Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+    <dict>
+        <key>CFBundleIdentifier</key>
+        <string>{{ xml .Identifier }}</string>
+        <key>CFBundleName</key>
+        <string>{{ xml .Name }}</string>
+        <key>DocSetPlatformFamily</key>
+        <string>{{ xml .PlatformFamily }}</string>
+        <key>isDashDocset</key>
+        <true/>
+        <key>dashIndexFilePath</key>
+        <string>{{ xml .IndexPage }}</string>
+        <key>DashDocSetFallbackURL</key>
+        <string>{{ xml .FallbackURL }}</string>
+        <key>isJavaScriptEnabled</key>
+        {{ if .EnableJavaScript }}<true/>{{ else }}<false/>{{ end }}
+        <key>DashDocSetDefaultFTSEnabled</key>
+        {{ if .FullTextSearch }}<true/>{{ else }}<false/>{{ end }}
+    </dict>
+</plist>
+`))
+
+// writePlist renders the Info.plist for a deliverable from it's TOC
+func (packager *Packager) writePlist(w io.Writer, toc *atlas.AtlasTOC) error {
+	return plistTemplate.Execute(w, struct {
+		registry.PlistSettings
+		Identifier string
+		Name       string
+	}{
+		PlistSettings: packager.getPlistSettings(toc),
+		Identifier:    getBundleIdentifier(toc),
+		Name:          registry.GetDocsetTitle(toc),
+	})
+}
@@ -0,0 +1,47 @@
+package packager
+
+import (
+	"testing"
+
+	"github.com/vividboarder/docset-sfdc/atlas"
+	"github.com/vividboarder/docset-sfdc/registry"
+)
+
+// TestPlistOverrides checks that overrides turn plist flags off as well as on and keep them when unset
+func TestPlistOverrides(t *testing.T) {
+	registry.Deliverables["plisttest"] = registry.Deliverable{
+		Name:  "plisttest",
+		Plist: registry.PlistSettings{PlatformFamily: "test", EnableJavaScript: true},
+	}
+	defer delete(registry.Deliverables, "plisttest")
+	toc := &atlas.AtlasTOC{Deliverable: "plisttest"}
+
+	on, off := true, false
+	cases := []struct {
+		name       string
+		overrides  PlistOverrides
+		javaScript bool
+		fts        bool
+	}{
+		{"unset", PlistOverrides{}, true, false},
+		{"off", PlistOverrides{EnableJavaScript: &off, FullTextSearch: &off}, false, false},
+		{"on", PlistOverrides{EnableJavaScript: &on, FullTextSearch: &on}, true, true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			docsetPackager := New("build", "out")
+			docsetPackager.PlistOverrides = c.overrides
+			settings := docsetPackager.getPlistSettings(toc)
+			if settings.EnableJavaScript != c.javaScript {
+				t.Errorf("JavaScript enabled is %t, expected %t", settings.EnableJavaScript, c.javaScript)
+			}
+			if settings.FullTextSearch != c.fts {
+				t.Errorf("Full text search is %t, expected %t", settings.FullTextSearch, c.fts)
+			}
+			if settings.PlatformFamily != "test" {
+				t.Errorf("Platform family is %s, expected the registered test", settings.PlatformFamily)
+			}
+		})
+	}
+}
@@ -0,0 +1,232 @@
+package packager
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/vividboarder/docset-sfdc/atlas"
+	"github.com/vividboarder/docset-sfdc/logging"
+)
+
+// publishDir is where the Dash User Contributions repo is cloned to, relative to the output dir
+const publishDir = "repotmp"
+
+// getEnv returns an environment variable or a default value if it's not set
+func getEnv(key string, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// PublishSettings are read from the environment so tokens are never passed as flags
+type PublishSettings struct {
+	// Repo that the release branch is pushed to
+	ForkRepo string
+	// Repo that the pull request is opened against
+	TargetRepo  string
+	GithubUser  string
+	GithubToken string
+}
+
+// getPublishSettings reads publish settings from the environment
+func getPublishSettings() (settings PublishSettings, err error) {
+	settings.ForkRepo = getEnv("FORK_REPO", "ViViDboarder/Dash-User-Contributions")
+	settings.TargetRepo = getEnv("TARGET_REPO", "ViViDboarder/Dash-User-Contributions")
+	// If no github user is provided, take it from the fork name
+	settings.GithubUser = getEnv("GITHUB_USER", strings.SplitN(settings.ForkRepo, "/", 2)[0])
+	settings.GithubToken = os.Getenv("GITHUB_TOKEN")
+	if settings.GithubToken == "" {
+		err = errors.New("Must provide $GITHUB_TOKEN as an environment variable")
+	}
+	return
+}
+
+// getPublishVersion returns the doc version shared by all archived deliverables
+func (packager *Packager) getPublishVersion(locale string, deliverables []string) (string, error) {
+	version := ""
+	var versions []string
+	for _, deliverable := range deliverables {
+		toc, err := atlas.LoadTOC(packager.BuildDir, locale, deliverable, "")
+		if err != nil {
+			return "", fmt.Errorf("Cannot publish %s. Could not read TOC: %s", deliverable, err.Error())
+		}
+		versions = append(versions, fmt.Sprintf("%s: %s", deliverable, toc.Version.DocVersion))
+		if version == "" {
+			version = toc.Version.DocVersion
+		} else if version != toc.Version.DocVersion {
+			return "", fmt.Errorf("One of the doc versions doesn't match. %s", strings.Join(versions, ", "))
+		}
+	}
+	if version == "" {
+		return "", errors.New("Cannot publish without deliverables")
+	}
+	return version, nil
+}
+
+// gitAuthEnv returns environment variables that authenticate git with GitHub using the token
+// The token is passed as a header so it never appears in a command line, remote url or log
+func gitAuthEnv(settings PublishSettings) []string {
+	credentials := base64.StdEncoding.EncodeToString([]byte(settings.GithubUser + ":" + settings.GithubToken))
+	return []string{
+		"GIT_CONFIG_COUNT=1",
+		"GIT_CONFIG_KEY_0=http.https://github.com/.extraheader",
+		"GIT_CONFIG_VALUE_0=AUTHORIZATION: basic " + credentials,
+	}
+}
+
+// runGit runs a git command in a directory, authenticated as the GitHub user
+func runGit(settings PublishSettings, dir string, args ...string) error {
+	logging.Debug("git %s", strings.Join(args, " "))
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(), gitAuthEnv(settings)...)
+
+	// If no global git configs exist, let's set some temporary values
+	if err := exec.Command("git", "config", "--global", "user.name").Run(); err != nil {
+		name := getEnv("GIT_COMMITTER_NAME", "ViViDboarder")
+		email := getEnv("GIT_COMMITTER_EMAIL", "ViViDboarder@gmail.com")
+		cmd.Env = append(
+			cmd.Env,
+			"GIT_COMMITTER_NAME="+name,
+			"GIT_COMMITTER_EMAIL="+email,
+			"GIT_AUTHOR_NAME="+name,
+			"GIT_AUTHOR_EMAIL="+email,
+		)
+	}
+	return cmd.Run()
+}
+
+// cloneOrPull makes sure an up to date shallow clone of the fork exists
+func cloneOrPull(settings PublishSettings, repoDir string) error {
+	cloneURL := "https://github.com/" + settings.ForkRepo
+	if _, err := os.Stat(repoDir); err == nil {
+		// Older clones kept the token in the remote url
+		err = runGit(settings, repoDir, "remote", "set-url", "origin", cloneURL)
+		if err != nil {
+			return err
+		}
+		err = runGit(settings, repoDir, "checkout", "master")
+		if err != nil {
+			return err
+		}
+		return runGit(settings, repoDir, "pull", "--ff-only", "origin", "master")
+	}
+
+	err := os.MkdirAll(filepath.Dir(repoDir), 0755)
+	if err != nil {
+		return err
+	}
+	return runGit(settings, filepath.Dir(repoDir), "clone", "--depth", "1", cloneURL, filepath.Base(repoDir))
+}
+
+// createPullRequest opens a pull request for a pushed branch and returns it's url
+func createPullRequest(settings PublishSettings, title string, body string, branch string) (string, error) {
+	payload, err := json.Marshal(map[string]string{
+		"title": title,
+		"body":  body,
+		"head":  settings.GithubUser + ":" + branch,
+		"base":  "master",
+	})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequest(
+		"POST",
+		fmt.Sprintf("https://api.github.com/repos/%s/pulls", settings.TargetRepo),
+		bytes.NewReader(payload),
+	)
+	if err != nil {
+		return "", err
+	}
+	req.SetBasicAuth(settings.GithubUser, settings.GithubToken)
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		logging.WarnIfError(resp.Body.Close())
+	}()
+
+	contents, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	result := struct {
+		HTMLURL string `json:"html_url"`
+	}{}
+	err = json.Unmarshal(contents, &result)
+	if err != nil || result.HTMLURL == "" {
+		return "", fmt.Errorf("Could not create pull request: %s", string(contents))
+	}
+	return result.HTMLURL, nil
+}
+
+// PublishArchives opens a pull request to Dash User Contributions with everything in the archive dir
+func (packager *Packager) PublishArchives(locale string, deliverables []string) error {
+	settings, err := getPublishSettings()
+	if err != nil {
+		return err
+	}
+
+	version, err := packager.getPublishVersion(locale, deliverables)
+	if err != nil {
+		return err
+	}
+	branch := "salesforce-" + version
+	logging.Info("Creating PR for %s to %s", settings.GithubUser, settings.TargetRepo)
+
+	repoDir := filepath.Join(packager.OutDir, publishDir, "Dash-User-Contributions")
+	err = cloneOrPull(settings, repoDir)
+	if err != nil {
+		return err
+	}
+
+	err = CopyPath(packager.ArchiveDir(), filepath.Join(repoDir, "docsets"))
+	if err != nil {
+		return err
+	}
+
+	err = runGit(settings, repoDir, "checkout", "-b", branch)
+	if err != nil {
+		return fmt.Errorf("Could not create release branch %s. Release likely already exists", branch)
+	}
+
+	title := "Update Salesforce docsets to " + version
+	for _, args := range [][]string{
+		{"add", "."},
+		{"commit", "-m", title},
+		{"push", "origin", "HEAD"},
+	} {
+		err = runGit(settings, repoDir, args...)
+		if err != nil {
+			return err
+		}
+	}
+
+	prURL, err := createPullRequest(
+		settings,
+		title,
+		"This branch contains auto-generated updates to version "+version,
+		branch,
+	)
+	if err != nil {
+		return err
+	}
+	logging.Info("Pull request created at %s", prURL)
+	return nil
+}
@@ -0,0 +1,192 @@
+// Package registry holds the settings used to build and package each Atlas deliverable
+package registry
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/vividboarder/docset-sfdc/atlas"
+	"github.com/vividboarder/docset-sfdc/classifier"
+	"github.com/vividboarder/docset-sfdc/logging"
+)
+
+// Deliverable describes how to build and package a Salesforce Atlas deliverable
+type Deliverable struct {
+	// Atlas name used in urls. Eg. apexcode
+	Name string
+	// Name used for the docset. Eg. Apex
+	FriendlyName string
+	// Base name of the icon files in the resources dir
+	Icon string
+	// Search aliases used in the docset.json
+	Aliases []string
+	// Rules used to find the type of each entry
+	Types []classifier.SupportedType
+	// Settings for the Info.plist. Empty values use the defaults
+	Plist PlistSettings
+}
+
+// PlistSettings are the configurable values of a docset Info.plist
+type PlistSettings struct {
+	// Used by Dash to pick the docset for a search keyword prefix
+	PlatformFamily string
+	// Online URL to open when a page isn't found in the docset
+	FallbackURL string
+	// Page that is opened when the docset is selected
+	IndexPage string
+	// Allow JavaScript to run in docset pages
+	EnableJavaScript bool
+	// Enable full text search by default
+	FullTextSearch bool
+}
+
+const defaultIcon = "cloud-icon"
+
+// CombinedDeliverable is the registered deliverable used to name and configure the combined docset
+const CombinedDeliverable = "platform"
+
+// Deliverables is a registry of all deliverables with known settings
+// Deliverables that are not registered can still be built using the defaults
+var Deliverables = map[string]Deliverable{
+	"apexcode": {
+		FriendlyName: "Apex",
+		Aliases:      []string{"apex", "salesforce", "sfdc"},
+		Types:        classifier.SupportedTypes,
+		Plist:        PlistSettings{PlatformFamily: "apex"},
+	},
+	"pages": {
+		FriendlyName: "Visualforce",
+		Aliases:      []string{"visualforce", "salesforce", "sfdc"},
+		Types:        classifier.SupportedTypes,
+		Plist:        PlistSettings{PlatformFamily: "vf"},
+	},
+	"lightning": {
+		FriendlyName: "Lightning",
+		Icon:         "bolt-icon",
+		Aliases:      []string{"lightning", "salesforce", "sfdc"},
+		Types:        classifier.SupportedTypes,
+		Plist:        PlistSettings{PlatformFamily: "lightning"},
+	},
+	"lwc": {
+		FriendlyName: "Lightning Web Components",
+		Icon:         "bolt-icon",
+		Aliases:      []string{"lwc", "lightning", "salesforce", "sfdc"},
+		Types:        classifier.LWCTypes,
+		Plist:        PlistSettings{PlatformFamily: "lwc"},
+	},
+	"sfdx_cli_reference": {
+		FriendlyName: "CLI",
+		Aliases:      []string{"sf", "sfdx", "cli", "salesforce", "sfdc"},
+		Types:        classifier.CLITypes,
+		Plist:        PlistSettings{PlatformFamily: "sfcli"},
+	},
+	// Not an Atlas deliverable. Used for the docset combining other deliverables
+	CombinedDeliverable: {
+		FriendlyName: "Platform",
+		Aliases:      []string{"salesforce", "sfdc", "platform"},
+		Plist:        PlistSettings{PlatformFamily: "salesforce"},
+	},
+	"api_rest": {
+		FriendlyName: "REST API",
+		Aliases:      []string{"rest", "salesforce", "sfdc"},
+		Types:        classifier.RestAPITypes,
+		Plist:        PlistSettings{PlatformFamily: "sfrest"},
+	},
+	"soql_sosl": {
+		FriendlyName: "SOQL and SOSL",
+		Aliases:      []string{"soql", "sosl", "salesforce", "sfdc"},
+		Types:        classifier.SOQLTypes,
+		Plist:        PlistSettings{PlatformFamily: "soql"},
+	},
+	"object_reference": {
+		FriendlyName: "Object Reference",
+		Aliases:      []string{"sobject", "objects", "salesforce", "sfdc"},
+		Types:        classifier.ObjectReferenceTypes,
+		Plist:        PlistSettings{PlatformFamily: "sobject"},
+	},
+	"api_meta": {
+		FriendlyName: "Metadata API",
+		Aliases:      []string{"metadata", "salesforce", "sfdc"},
+		Types:        classifier.MetadataAPITypes,
+		Plist:        PlistSettings{PlatformFamily: "metadata"},
+	},
+}
+
+// Get returns the registered settings for a deliverable with defaults filled in
+func Get(name string) Deliverable {
+	deliverable, ok := Deliverables[name]
+	if !ok {
+		logging.Debug("%s is not a registered deliverable. Using defaults", name)
+	}
+
+	deliverable.Name = name
+	if deliverable.Icon == "" {
+		deliverable.Icon = defaultIcon
+	}
+	if deliverable.Aliases == nil {
+		deliverable.Aliases = []string{name, "salesforce", "sfdc"}
+	}
+	if deliverable.Types == nil {
+		deliverable.Types = classifier.SupportedTypes
+	}
+	if deliverable.Plist.PlatformFamily == "" {
+		deliverable.Plist.PlatformFamily = name
+	}
+	return deliverable
+}
+
+// IsRegistered returns true if the deliverable has registered settings
+func (deliverable Deliverable) IsRegistered() bool {
+	_, ok := Deliverables[deliverable.Name]
+	return ok
+}
+
+// GetFriendlyName returns the registered friendly name or a capitalized version of the name
+func (deliverable Deliverable) GetFriendlyName() string {
+	if deliverable.FriendlyName != "" {
+		return deliverable.FriendlyName
+	}
+	if deliverable.Name == "" {
+		return deliverable.Name
+	}
+	return strings.ToUpper(deliverable.Name[:1]) + deliverable.Name[1:]
+}
+
+// GetDisplayName returns the name used to display a deliverable
+// Deliverables that are not registered use the title of the documentation
+func GetDisplayName(toc *atlas.AtlasTOC) string {
+	deliverable := Get(toc.Deliverable)
+	if !deliverable.IsRegistered() && toc.DocTitle != "" {
+		return toc.DocTitle
+	}
+	return deliverable.GetFriendlyName()
+}
+
+// getLanguageLabel returns the name of the language for locales other than the default
+func getLanguageLabel(toc *atlas.AtlasTOC) string {
+	if toc.Locale == atlas.DefaultLocale {
+		return ""
+	}
+	if toc.Language.Label != "" {
+		return toc.Language.Label
+	}
+	return toc.Locale
+}
+
+// GetDocsetName returns the name of the docset for a deliverable
+// Locales other than the default are labeled with their language
+func GetDocsetName(toc *atlas.AtlasTOC) string {
+	name := "Salesforce " + GetDisplayName(toc)
+	if label := getLanguageLabel(toc); label != "" {
+		name = fmt.Sprintf("%s (%s)", name, label)
+	}
+	return name
+}
+
+// GetDocsetTitle returns the name of the docset labeled with the version if it was pinned
+func GetDocsetTitle(toc *atlas.AtlasTOC) string {
+	if toc.VersionPinned {
+		return fmt.Sprintf("%s %s", GetDocsetName(toc), toc.Version.ReleaseVersion)
+	}
+	return GetDocsetName(toc)
+}
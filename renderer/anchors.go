@@ -0,0 +1,183 @@
+package renderer
+
+import (
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/vividboarder/docset-sfdc/logging"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// dashAnchorPrefix starts the name of every anchor Dash uses for the table of contents
+const dashAnchorPrefix = "//apple_ref/cpp/"
+
+// contentTableHeaders are headers of the first column of tables that list entries in the content
+var contentTableHeaders = []string{"field", "field name"}
+
+// contentNamePattern matches names found in content tables. Eg. AnnualRevenue or Custom__c
+var contentNamePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+
+// flagPattern matches the long name of a command line flag. Eg. --target-org
+var flagPattern = regexp.MustCompile(`--[A-Za-z][A-Za-z0-9-]*`)
+
+// GetDashAnchor returns the anchor name for an entry of a type
+func GetDashAnchor(typeName string, name string) string {
+	return dashAnchorPrefix + typeName + "/" + url.PathEscape(name)
+}
+
+// ParseDashAnchor returns the type and name of an entry from an anchor name
+func ParseDashAnchor(anchor string) (typeName string, name string, ok bool) {
+	if !strings.HasPrefix(anchor, dashAnchorPrefix) {
+		return
+	}
+	parts := strings.SplitN(strings.TrimPrefix(anchor, dashAnchorPrefix), "/", 2)
+	if len(parts) != 2 {
+		return
+	}
+	name, err := url.PathUnescape(parts[1])
+	if err != nil {
+		return
+	}
+	return parts[0], name, true
+}
+
+// isContentTable returns true if the first header of a table is one of the contentTableHeaders
+func isContentTable(table *html.Node) bool {
+	header := findElement(table, atom.Th)
+	if header == nil {
+		return false
+	}
+	text := strings.ToLower(strings.TrimSpace(textContent(header)))
+	return contains(contentTableHeaders, text)
+}
+
+// findElement returns the first element of a type under a node
+func findElement(node *html.Node, a atom.Atom) *html.Node {
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode {
+			continue
+		}
+		if child.DataAtom == a {
+			return child
+		}
+		if found := findElement(child, a); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// findElements returns all elements of a type under a node, without descending into matches
+func findElements(node *html.Node, a atom.Atom) (found []*html.Node) {
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode {
+			continue
+		}
+		if child.DataAtom == a {
+			found = append(found, child)
+			continue
+		}
+		found = append(found, findElements(child, a)...)
+	}
+	return
+}
+
+// insertDashAnchor adds a Dash anchor for an entry as the first child of a node
+func insertDashAnchor(node *html.Node, typeName string, name string) {
+	anchor := &html.Node{
+		Type:     html.ElementNode,
+		Data:     "a",
+		DataAtom: atom.A,
+		Attr: []html.Attribute{
+			{Key: "name", Val: GetDashAnchor(typeName, name)},
+			{Key: "class", Val: "dashAnchor"},
+		},
+	}
+	node.InsertBefore(anchor, node.FirstChild)
+}
+
+// flagTypeName is the type of entries found from command line flags in definition lists
+const flagTypeName = "Option"
+
+// addContentAnchors adds a Dash anchor to each entry found in the content
+// Options are the command line flags in definition lists (Eg. project deploy start --target-org)
+// and every other type is found in content tables (Eg. Account.AnnualRevenue)
+// sections maps ids in the content to the names of the entries they document. Flags are
+// prefixed with the name of the section they are in, or the parent entry outside of any section
+// Entries are skipped if there is no parent entry or section they belong to
+func addContentAnchors(container *html.Node, typeName string, parentName string, sections map[string]string) {
+	if typeName == flagTypeName {
+		addFlagAnchors(container, typeName, parentName, sections)
+		return
+	}
+	if parentName == "" {
+		return
+	}
+
+	for _, table := range findElements(container, atom.Table) {
+		if !isContentTable(table) {
+			continue
+		}
+		for _, row := range findElements(table, atom.Tr) {
+			cell := findElement(row, atom.Td)
+			if cell == nil {
+				continue
+			}
+			fields := strings.Fields(textContent(cell))
+			if len(fields) == 0 || !contentNamePattern.MatchString(fields[0]) {
+				continue
+			}
+			insertDashAnchor(cell, typeName, parentName+"."+fields[0])
+		}
+	}
+}
+
+// addFlagAnchors adds a Dash anchor to each command line flag in a definition list
+// Sections are tracked in document order, so ids can be on a wrapping element or on a heading
+// Flags before the first section are skipped if there is no command they belong to
+func addFlagAnchors(node *html.Node, typeName string, commandName string, sections map[string]string) string {
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode {
+			continue
+		}
+		if name, ok := sections[getAttr(child, "id")]; ok {
+			commandName = name
+		}
+
+		if child.DataAtom == atom.Dt {
+			text := strings.TrimSpace(textContent(child))
+			if flag := flagPattern.FindString(text); commandName != "" && flag != "" && strings.HasPrefix(text, "-") {
+				insertDashAnchor(child, typeName, commandName+" "+flag)
+			}
+			continue
+		}
+		commandName = addFlagAnchors(child, typeName, commandName, sections)
+	}
+	return commandName
+}
+
+// ReadContentAnchors returns the names of all Dash anchors of a type in a saved page
+func ReadContentAnchors(filePath string, typeName string) (anchors []string, err error) {
+	ifile, err := os.Open(filePath)
+	if err != nil {
+		return
+	}
+	defer func() {
+		logging.WarnIfError(ifile.Close())
+	}()
+
+	doc, err := html.Parse(ifile)
+	if err != nil {
+		return
+	}
+	for _, link := range findElements(doc, atom.A) {
+		name := getAttr(link, "name")
+		if anchorType, _, ok := ParseDashAnchor(name); ok && anchorType == typeName {
+			anchors = append(anchors, name)
+		}
+	}
+	return
+}
@@ -0,0 +1,94 @@
+package renderer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/vividboarder/docset-sfdc/classifier"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// contentAnchorNames renders content and returns the type and name of every Dash anchor added to it
+func contentAnchorNames(t *testing.T, content string, entryType classifier.SupportedType, name string, sections map[string]string) (names []string) {
+	rendered, err := (&Renderer{}).RenderContent("page.htm", content, entryType, name, sections)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := html.Parse(strings.NewReader(rendered))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, link := range findElements(doc, atom.A) {
+		if typeName, name, ok := ParseDashAnchor(getAttr(link, "name")); ok {
+			names = append(names, typeName+" "+name)
+		}
+	}
+	return
+}
+
+// TestContentAnchors checks which entries in the content are anchored and who they belong to
+func TestContentAnchors(t *testing.T) {
+	object := classifier.SupportedType{TypeName: "Object", ParseContent: true, ContentTypeName: "Field"}
+	command := classifier.SupportedType{TypeName: "Command", ParseContent: true, ContentTypeName: "Option"}
+	topic := command
+	topic.IsContainer = true
+	sections := map[string]string{"deploy": "project deploy start", "retrieve": "project retrieve start"}
+
+	cases := []struct {
+		name      string
+		entryType classifier.SupportedType
+		parent    string
+		content   string
+		expected  []string
+	}{
+		{
+			"fields",
+			object,
+			"Account",
+			`<table><tr><th>Field Name</th></tr><tr><td><span>Name</span></td></tr><tr><td>2 more fields</td></tr></table>
+			<table><tr><th>Usage</th></tr><tr><td>Ignored</td></tr></table>`,
+			[]string{"Field Account.Name"},
+		},
+		{
+			"command flags",
+			command,
+			"project deploy start",
+			`<dl><dt>-o | --target-org TARGET-ORG</dt><dd>Org</dd><dt>Example --not-a-flag</dt><dd>Text</dd></dl>`,
+			[]string{"Option project deploy start --target-org"},
+		},
+		{
+			"topic sections",
+			topic,
+			"project Commands",
+			`<div id="deploy"><dl><dt>--dry-run</dt></dl></div>
+			<h2 id="retrieve">project retrieve start</h2><dl><dt>--target-org</dt></dl>`,
+			[]string{"Option project deploy start --dry-run", "Option project retrieve start --target-org"},
+		},
+		{
+			"topic flags before the first command",
+			topic,
+			"project Commands",
+			`<dl><dt>--flags-dir</dt></dl>
+			<h2 id="deploy">project deploy start</h2><dl><dt>--dry-run</dt></dl>`,
+			[]string{"Option project deploy start --dry-run"},
+		},
+		{
+			"topic sections that aren't commands",
+			topic,
+			"project Commands",
+			`<h2 id="usage">Usage</h2><dl><dt>--json</dt></dl>
+			<h2 id="examples">Examples</h2><dl><dt>--api-version</dt></dl>`,
+			nil,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			actual := contentAnchorNames(t, c.content, c.entryType, c.parent, sections)
+			if strings.Join(actual, "|") != strings.Join(c.expected, "|") {
+				t.Errorf("Anchored %v, expected %v", actual, c.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,517 @@
+package renderer
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/vividboarder/docset-sfdc/logging"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+const highlightFile = "sfdashc-highlight.css"
+
+// highlightCSS styles the spans created by highlighting, with colors for both light and dark themes
+const highlightCSS = `.hl-keyword { color: #0000ff; font-weight: bold; }
+.hl-type { color: #267f99; }
+.hl-string { color: #a31515; }
+.hl-number { color: #098658; }
+.hl-comment { color: #008000; font-style: italic; }
+.hl-annotation { color: #795e26; }
+.hl-tag { color: #800000; }
+.hl-attr { color: #e50000; }
+.hl-expression { color: #af00db; }
+@media (prefers-color-scheme: dark) {
+	.hl-keyword { color: #569cd6; }
+	.hl-type { color: #4ec9b0; }
+	.hl-string { color: #ce9178; }
+	.hl-number { color: #b5cea8; }
+	.hl-comment { color: #6a9955; }
+	.hl-annotation { color: #dcdcaa; }
+	.hl-tag { color: #569cd6; }
+	.hl-attr { color: #9cdcfe; }
+	.hl-expression { color: #c586c0; }
+}
+`
+
+// Languages that can be highlighted
+const (
+	langApex        = "apex"
+	langSOQL        = "soql"
+	langVisualforce = "visualforce"
+	langJavaScript  = "javascript"
+)
+
+// codeLanguage describes how to tokenize a language
+type codeLanguage struct {
+	Keywords      map[string]bool
+	Types         map[string]bool
+	CaseSensitive bool
+	LineComment   string
+	BlockComments bool
+	Quotes        string
+	Annotations   bool
+}
+
+func wordSet(words string) map[string]bool {
+	set := map[string]bool{}
+	for _, word := range strings.Fields(words) {
+		set[word] = true
+	}
+	return set
+}
+
+var soqlKeywords = "select from where and or not in like limit offset order by asc desc nulls first last " +
+	"group having rollup cube with data category above below at above_or_below includes excludes " +
+	"typeof when then else end using scope find returning all rows for view reference update tracking " +
+	"viewstat fields null true false count count_distinct sum avg min max format tolabel convertcurrency " +
+	"calendar_year calendar_month day_only security_enforced user_mode system_mode"
+
+var codeLanguages = map[string]codeLanguage{
+	langApex: {
+		Keywords: wordSet("abstract after before break catch class continue delete do else enum extends " +
+			"false final finally for global if implements insert instanceof interface merge new null " +
+			"override private protected public return static super switch on testmethod this throw " +
+			"transient trigger true try undelete update upsert virtual void webservice when while " +
+			"with without sharing inherited get set " + soqlKeywords),
+		Types: wordSet("blob boolean date datetime decimal double id integer list long map object set " +
+			"sobject string time database system schema test"),
+		LineComment:   "//",
+		BlockComments: true,
+		Quotes:        "'",
+		Annotations:   true,
+	},
+	langSOQL: {
+		Keywords: wordSet(soqlKeywords),
+		Quotes:   "'",
+	},
+	langJavaScript: {
+		Keywords: wordSet("async await break case catch class const continue debugger default delete do " +
+			"else export extends false finally for from function if import in instanceof let new null " +
+			"of return static super switch this throw true try typeof undefined var void while with yield"),
+		Types: wordSet("Array Boolean Date Error JSON Map Math Number Object Promise RegExp Set String " +
+			"console document window LightningElement"),
+		CaseSensitive: true,
+		LineComment:   "//",
+		BlockComments: true,
+		Quotes:        "'\"`",
+		Annotations:   true,
+	},
+}
+
+// Patterns used to guess the language of a code block
+var (
+	classLanguagePattern = regexp.MustCompile(`(?i)(?:brush:\s*|language-|lang-)([a-z]+)`)
+	soqlPattern          = regexp.MustCompile(`(?is)^\s*\[?\s*(select\s.+\sfrom\s|find\s+['{])`)
+	markupPattern        = regexp.MustCompile(`(?s)^\s*<[!?a-zA-Z]`)
+	jsPattern            = regexp.MustCompile(`=>|\b(function\s*\(|const\s+\w+\s*=|let\s+\w+\s*=|import\s+.*\s+from\s+['"]|export\s+default|document\.|console\.)`)
+	apexPattern          = regexp.MustCompile(`(?i)\b(public|private|global|protected)\s+(static\s+)?(with sharing\s+|without sharing\s+)?(class|void|string|integer|boolean|list<|map<|set<)|\bsystem\.(debug|assert)|@istest|\btrigger\s+\w+\s+on\b`)
+)
+
+// languageAliases maps names used in class attributes to a highlighting language
+var languageAliases = map[string]string{
+	"apex":        langApex,
+	"java":        langApex,
+	"soql":        langSOQL,
+	"sosl":        langSOQL,
+	"sql":         langSOQL,
+	"visualforce": langVisualforce,
+	"vf":          langVisualforce,
+	"xml":         langVisualforce,
+	"html":        langVisualforce,
+	"markup":      langVisualforce,
+	"aura":        langVisualforce,
+	"javascript":  langJavaScript,
+	"js":          langJavaScript,
+}
+
+// inferLanguage returns the language of a code block using its class or its content
+func inferLanguage(class string, code string) string {
+	if match := classLanguagePattern.FindStringSubmatch(class); match != nil {
+		if lang, ok := languageAliases[strings.ToLower(match[1])]; ok {
+			return lang
+		}
+	}
+
+	switch {
+	case markupPattern.MatchString(code):
+		return langVisualforce
+	case soqlPattern.MatchString(code):
+		return langSOQL
+	case apexPattern.MatchString(code):
+		return langApex
+	case jsPattern.MatchString(code):
+		return langJavaScript
+	}
+	return ""
+}
+
+// highlightBlocks replaces the text of all code blocks under the node with highlighted spans
+func highlightBlocks(node *html.Node) {
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode {
+			continue
+		}
+		if child.DataAtom == atom.Pre {
+			highlightBlock(child)
+			continue
+		}
+		highlightBlocks(child)
+	}
+}
+
+// highlightBlock highlights a single pre element if it's language can be inferred
+func highlightBlock(pre *html.Node) {
+	// Links inside code would be lost, so leave those blocks alone
+	if containsElement(pre, atom.A) {
+		return
+	}
+
+	segments := codeSegments(pre, nil)
+	code := ""
+	for _, segment := range segments {
+		code += segment.Text
+	}
+	lang := inferLanguage(getAttr(pre, "class"), code)
+	if lang == "" {
+		return
+	}
+
+	var tokens []codeToken
+	if lang == langVisualforce {
+		tokens = tokenizeMarkup(code)
+	} else {
+		tokens = tokenizeCode(code, codeLanguages[lang])
+	}
+
+	for child := pre.FirstChild; child != nil; child = pre.FirstChild {
+		pre.RemoveChild(child)
+	}
+	appendTokens(pre, segments, tokens)
+	logging.Debug("Highlighted code block as %s", lang)
+}
+
+// containsElement returns true if the node has a descendant of the given type
+func containsElement(node *html.Node, a atom.Atom) bool {
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && (child.DataAtom == a || containsElement(child, a)) {
+			return true
+		}
+	}
+	return false
+}
+
+// textContent returns all text under a node
+func textContent(node *html.Node) string {
+	if node.Type == html.TextNode {
+		return node.Data
+	}
+	text := ""
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && child.DataAtom == atom.Br {
+			text += "\n"
+			continue
+		}
+		text += textContent(child)
+	}
+	return text
+}
+
+// codeSegment is a piece of text in a code block along with the inline elements, like <b> or <em>, it's inside of
+type codeSegment struct {
+	Text     string
+	Elements []*html.Node
+}
+
+// codeSegments returns all text under a node, split wherever it's inline elements change
+func codeSegments(node *html.Node, elements []*html.Node) (segments []codeSegment) {
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		switch {
+		case child.Type == html.TextNode:
+			segments = append(segments, codeSegment{child.Data, elements})
+		case child.Type == html.ElementNode && child.DataAtom == atom.Br:
+			segments = append(segments, codeSegment{"\n", elements})
+		case child.Type == html.ElementNode:
+			nested := append(elements[:len(elements):len(elements)], child)
+			segments = append(segments, codeSegments(child, nested)...)
+		}
+	}
+	return
+}
+
+// appendTokens adds highlighted tokens to a node, splitting them where the segments they came
+// from change so the inline elements around the original text are kept
+func appendTokens(node *html.Node, segments []codeSegment, tokens []codeToken) {
+	// Inline elements of the original segments that are open, along with the copies added to the node
+	var open, copies []*html.Node
+	parent := func() *html.Node {
+		if len(copies) == 0 {
+			return node
+		}
+		return copies[len(copies)-1]
+	}
+
+	segmentIndex, offset := 0, 0
+	for _, token := range tokens {
+		text := token.Text
+		for len(text) > 0 && segmentIndex < len(segments) {
+			segment := segments[segmentIndex]
+			if offset >= len(segment.Text) {
+				segmentIndex, offset = segmentIndex+1, 0
+				continue
+			}
+			length := len(segment.Text) - offset
+			if length > len(text) {
+				length = len(text)
+			}
+
+			// Close elements the segment isn't in and open the ones it is
+			common := 0
+			for common < len(open) && common < len(segment.Elements) && open[common] == segment.Elements[common] {
+				common++
+			}
+			open, copies = open[:common], copies[:common]
+			for _, element := range segment.Elements[common:] {
+				elementCopy := &html.Node{
+					Type:     element.Type,
+					Data:     element.Data,
+					DataAtom: element.DataAtom,
+					Attr:     append([]html.Attribute(nil), element.Attr...),
+				}
+				parent().AppendChild(elementCopy)
+				open, copies = append(open, element), append(copies, elementCopy)
+			}
+
+			parent().AppendChild(tokenNode(text[:length], token.Class))
+			text, offset = text[length:], offset+length
+		}
+	}
+}
+
+// tokenNode returns a text node, wrapped in a span if the token is highlighted
+func tokenNode(text string, class string) *html.Node {
+	textNode := &html.Node{Type: html.TextNode, Data: text}
+	if class == "" {
+		return textNode
+	}
+	span := &html.Node{
+		Type:     html.ElementNode,
+		Data:     "span",
+		DataAtom: atom.Span,
+		Attr:     []html.Attribute{{Key: "class", Val: "hl-" + class}},
+	}
+	span.AppendChild(textNode)
+	return span
+}
+
+// codeToken is a piece of code with the class used to style it
+type codeToken struct {
+	Text  string
+	Class string
+}
+
+// tokenizer builds a list of tokens, merging adjacent plain text
+type tokenizer struct {
+	tokens []codeToken
+}
+
+func (t *tokenizer) add(text string, class string) {
+	if text == "" {
+		return
+	}
+	last := len(t.tokens) - 1
+	if class == "" && last >= 0 && t.tokens[last].Class == "" {
+		t.tokens[last].Text += text
+		return
+	}
+	t.tokens = append(t.tokens, codeToken{Text: text, Class: class})
+}
+
+// tokenizeCode splits source code for a C-like or query language into tokens
+func tokenizeCode(code string, lang codeLanguage) []codeToken {
+	t := &tokenizer{}
+	runes := []rune(code)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case lang.LineComment != "" && hasRunePrefix(runes[i:], lang.LineComment):
+			j := i
+			for j < len(runes) && runes[j] != '\n' {
+				j++
+			}
+			t.add(string(runes[i:j]), "comment")
+			i = j
+		case lang.BlockComments && hasRunePrefix(runes[i:], "/*"):
+			j := i + 2
+			for j < len(runes) && !hasRunePrefix(runes[j:], "*/") {
+				j++
+			}
+			if j < len(runes) {
+				j += 2
+			}
+			t.add(string(runes[i:j]), "comment")
+			i = j
+		case strings.ContainsRune(lang.Quotes, r):
+			j := i + 1
+			for j < len(runes) && runes[j] != r {
+				if runes[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(runes) {
+				j = len(runes) - 1
+			}
+			t.add(string(runes[i:j+1]), "string")
+			i = j + 1
+		case lang.Annotations && r == '@':
+			j := i + 1
+			for j < len(runes) && isWordRune(runes[j]) {
+				j++
+			}
+			t.add(string(runes[i:j]), "annotation")
+			i = j
+		case unicode.IsDigit(r):
+			j := i
+			for j < len(runes) && (unicode.IsDigit(runes[j]) || runes[j] == '.' || runes[j] == 'L' || runes[j] == 'l') {
+				j++
+			}
+			t.add(string(runes[i:j]), "number")
+			i = j
+		case isWordRune(r):
+			j := i
+			for j < len(runes) && isWordRune(runes[j]) {
+				j++
+			}
+			word := string(runes[i:j])
+			lookup := word
+			if !lang.CaseSensitive {
+				lookup = strings.ToLower(word)
+			}
+			switch {
+			case lang.Keywords[lookup]:
+				t.add(word, "keyword")
+			case lang.Types[lookup]:
+				t.add(word, "type")
+			default:
+				t.add(word, "")
+			}
+			i = j
+		default:
+			t.add(string(r), "")
+			i++
+		}
+	}
+	return t.tokens
+}
+
+// hasRunePrefix returns true if the runes begin with the prefix
+func hasRunePrefix(runes []rune, prefix string) bool {
+	i := 0
+	for _, r := range prefix {
+		if i >= len(runes) || runes[i] != r {
+			return false
+		}
+		i++
+	}
+	return true
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || r == '$' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// tokenizeMarkup splits Visualforce, Aura or other xml-like markup into tokens
+func tokenizeMarkup(code string) []codeToken {
+	t := &tokenizer{}
+	for len(code) > 0 {
+		switch {
+		case strings.HasPrefix(code, "<!--"):
+			end := strings.Index(code, "-->")
+			if end < 0 {
+				end = len(code)
+			} else {
+				end += 3
+			}
+			t.add(code[:end], "comment")
+			code = code[end:]
+		case strings.HasPrefix(code, "{!"):
+			end := strings.Index(code, "}")
+			if end < 0 {
+				end = len(code) - 1
+			}
+			t.add(code[:end+1], "expression")
+			code = code[end+1:]
+		case strings.HasPrefix(code, "<") && len(code) > 1 && (code[1] == '/' || code[1] == '!' || code[1] == '?' || unicode.IsLetter(rune(code[1]))):
+			end := strings.Index(code, ">")
+			if end < 0 {
+				end = len(code) - 1
+			}
+			tokenizeTag(t, code[:end+1])
+			code = code[end+1:]
+		default:
+			next := strings.IndexAny(code[1:], "<{")
+			if next < 0 {
+				next = len(code)
+			} else {
+				next++
+			}
+			t.add(code[:next], "")
+			code = code[next:]
+		}
+	}
+	return t.tokens
+}
+
+// tokenizeTag splits a single markup tag into a tag name, attributes and values
+func tokenizeTag(t *tokenizer, tag string) {
+	nameEnd := strings.IndexFunc(tag[1:], func(r rune) bool {
+		return unicode.IsSpace(r) || r == '>' || r == '/'
+	})
+	if nameEnd < 0 {
+		t.add(tag, "tag")
+		return
+	}
+	nameEnd++
+	if nameEnd == 1 && tag[1] == '/' {
+		// Closing tag
+		t.add(tag, "tag")
+		return
+	}
+	t.add(tag[:nameEnd], "tag")
+
+	rest := tag[nameEnd:]
+	for len(rest) > 0 {
+		r := rune(rest[0])
+		switch {
+		case r == '"' || r == '\'':
+			end := strings.IndexRune(rest[1:], r)
+			if end < 0 {
+				end = len(rest) - 1
+			} else {
+				end++
+			}
+			value := rest[:end+1]
+			if strings.Contains(value, "{!") {
+				t.add(value, "expression")
+			} else {
+				t.add(value, "string")
+			}
+			rest = rest[end+1:]
+		case unicode.IsLetter(r):
+			end := strings.IndexAny(rest, "= \t\n/>")
+			if end < 0 {
+				end = len(rest)
+			}
+			t.add(rest[:end], "attr")
+			rest = rest[end:]
+		case r == '>' || r == '/':
+			t.add(rest, "tag")
+			rest = ""
+		default:
+			t.add(string(r), "")
+			rest = rest[1:]
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package renderer
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// tokenString formats tokens as class:text, separated by |, leaving out the class of plain text
+func tokenString(tokens []codeToken) string {
+	var parts []string
+	for _, token := range tokens {
+		if token.Class == "" {
+			parts = append(parts, token.Text)
+			continue
+		}
+		parts = append(parts, token.Class+":"+token.Text)
+	}
+	return strings.Join(parts, "|")
+}
+
+// TestInferLanguage checks languages inferred from the class and from the content of code blocks
+func TestInferLanguage(t *testing.T) {
+	cases := []struct {
+		name     string
+		class    string
+		code     string
+		expected string
+	}{
+		{"brush class", "brush: java", "x = 1;", langApex},
+		{"language class", "codeblock language-JS", "x = 1;", langJavaScript},
+		{"lang class", "lang-sosl", "x = 1;", langSOQL},
+		{"unknown class falls back to content", "language-ruby", "SELECT Id FROM Account", langSOQL},
+		{"markup", "", "  <apex:page controller=\"X\">", langVisualforce},
+		{"soql", "", "[SELECT Id, Name\nFROM Account]", langSOQL},
+		{"sosl", "", "FIND 'Acme' IN ALL FIELDS", langSOQL},
+		{"apex class", "", "public with sharing class Foo {}", langApex},
+		{"apex debug", "", "System.debug(x);", langApex},
+		{"apex trigger", "", "trigger Before on Account (before insert) {}", langApex},
+		{"javascript", "", "const x = 1;", langJavaScript},
+		{"javascript arrow", "", "items.map(item => item.id)", langJavaScript},
+		{"plain text", "", "Enter your name and click Save.", ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			actual := inferLanguage(c.class, c.code)
+			if actual != c.expected {
+				t.Errorf("Inferred %q, expected %q", actual, c.expected)
+			}
+		})
+	}
+}
+
+// TestTokenizeCode checks tokens of Apex and JavaScript, including unterminated strings and comments
+func TestTokenizeCode(t *testing.T) {
+	cases := []struct {
+		name     string
+		lang     string
+		code     string
+		expected string
+	}{
+		{"keywords and types", langApex, "public String s", "keyword:public| |type:String| s"},
+		{"case insensitive", langApex, "SELECT", "keyword:SELECT"},
+		{"case sensitive", langJavaScript, "Const const", "Const |keyword:const"},
+		{"annotation", langApex, "@isTest void", "annotation:@isTest| |keyword:void"},
+		{"number", langApex, "x = 10L;", "x = |number:10L|;"},
+		{"line comment", langApex, "x; // note\ny", "x; |comment:// note|\ny"},
+		{"block comment", langApex, "/* a\nb */x", "comment:/* a\nb */|x"},
+		{"unterminated block comment", langApex, "x /* a", "x |comment:/* a"},
+		{"string", langApex, "s = 'a';", "s = |string:'a'|;"},
+		{"escaped quote", langApex, `'it\'s' x`, `string:'it\'s'| x`},
+		{"unterminated string", langApex, "s = 'abc", "s = |string:'abc"},
+		{"unterminated string ending in an escape", langApex, `s = 'ab\`, `s = |string:'ab\`},
+		{"other quotes", langJavaScript, "`a` \"b\"", "string:`a`| |string:\"b\""},
+		{"quotes of other languages", langApex, `"a"`, `"a"`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			tokens := tokenizeCode(c.code, codeLanguages[c.lang])
+			if actual := tokenString(tokens); actual != c.expected {
+				t.Errorf("Tokenized %q as %q, expected %q", c.code, actual, c.expected)
+			}
+		})
+	}
+}
+
+// TestTokenizeMarkup checks tokens of markup, including unterminated expressions and attributes
+func TestTokenizeMarkup(t *testing.T) {
+	cases := []struct {
+		name     string
+		code     string
+		expected string
+	}{
+		{"tag", `<apex:page controller="X">`, `tag:<apex:page| |attr:controller|=|string:"X"|tag:>`},
+		{"closing tag", `</apex:page>`, `tag:</apex:page>`},
+		{"self closing tag", `<br/>`, `tag:<br|tag:/>`},
+		{"self closing tag with attribute", `<c:x a='1' />`, `tag:<c:x| |attr:a|=|string:'1'| |tag:/>`},
+		{"expression attribute", `<p v="{!x}">`, `tag:<p| |attr:v|=|expression:"{!x}"|tag:>`},
+		{"expression", `Hi {!name}!`, `Hi |expression:{!name}|!`},
+		{"unterminated expression", `Hi {!name`, `Hi |expression:{!name`},
+		{"braces without expression", `a {b} c`, `a {b} c`},
+		{"unterminated attribute", `<p v="x>`, `tag:<p| |attr:v|=|string:"x>`},
+		{"unterminated tag", `<p v`, `tag:<p| |attr:v`},
+		{"comment", `<!-- a --><p>`, `comment:<!-- a -->|tag:<p|tag:>`},
+		{"unterminated comment", `<!-- a`, `comment:<!-- a`},
+		{"less than", `a < b`, `a < b`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if actual := tokenString(tokenizeMarkup(c.code)); actual != c.expected {
+				t.Errorf("Tokenized %q as %q, expected %q", c.code, actual, c.expected)
+			}
+		})
+	}
+}
+
+// highlightFragment parses html as the content of a body, highlights it and renders it back
+func highlightFragment(t *testing.T, content string) string {
+	body := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := html.ParseFragment(strings.NewReader(content), body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, node := range nodes {
+		body.AppendChild(node)
+	}
+
+	highlightBlocks(body)
+
+	var buf bytes.Buffer
+	for child := body.FirstChild; child != nil; child = child.NextSibling {
+		if err := html.Render(&buf, child); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return buf.String()
+}
+
+// TestHighlightBlocks checks which code blocks are highlighted and that inline elements are kept
+func TestHighlightBlocks(t *testing.T) {
+	cases := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{
+			"highlighted",
+			`<div><pre class="codeSample">System.debug(x);</pre></div>`,
+			`<div><pre class="codeSample"><span class="hl-type">System</span>.debug(x);</pre></div>`,
+		},
+		{
+			"unknown language",
+			`<pre>Click Save.</pre>`,
+			`<pre>Click Save.</pre>`,
+		},
+		{
+			"links",
+			`<pre>public void <a href="x.htm">run</a>()</pre>`,
+			`<pre>public void <a href="x.htm">run</a>()</pre>`,
+		},
+		{
+			"line breaks",
+			`<pre>public void x;<br/>y</pre>`,
+			`<pre><span class="hl-keyword">public</span> <span class="hl-keyword">void</span> x;` + "\n" + `y</pre>`,
+		},
+		{
+			"emphasis",
+			`<pre>public <b>void run</b>()</pre>`,
+			`<pre><span class="hl-keyword">public</span> <b><span class="hl-keyword">void</span> run</b>()</pre>`,
+		},
+		{
+			"emphasis inside a token",
+			`<pre>public void r<em class="x">u</em>n() {}</pre>`,
+			`<pre><span class="hl-keyword">public</span> <span class="hl-keyword">void</span> r<em class="x">u</em>n() {}</pre>`,
+		},
+		{
+			"emphasis splitting a token",
+			`<pre>public <b>vo</b>id x</pre>`,
+			`<pre><span class="hl-keyword">public</span> <b><span class="hl-keyword">vo</span></b><span class="hl-keyword">id</span> x</pre>`,
+		},
+		{
+			"nested emphasis",
+			`<pre>public <b>void <i>run</i> x</b></pre>`,
+			`<pre><span class="hl-keyword">public</span> <b><span class="hl-keyword">void</span> <i>run</i> x</b></pre>`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if actual := highlightFragment(t, c.content); actual != c.expected {
+				t.Errorf("Highlighted to %s, expected %s", actual, c.expected)
+			}
+		})
+	}
+}
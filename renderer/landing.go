@@ -0,0 +1,117 @@
+package renderer
+
+import (
+	"html/template"
+	"io"
+
+	"github.com/vividboarder/docset-sfdc/atlas"
+)
+
+// landingTemplate is the index page for a deliverable, linking every page in the TOC
+var landingTemplate = template.Must(template.New("landing").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta http-equiv="Content-Type" content="text/html; charset=UTF-8" />
+<title>{{ .DocTitle }}</title>
+{{- range .CSSFiles }}
+<link rel="stylesheet" type="text/css" href="{{ . }}">
+{{- end }}
+<style>
+body { padding: 15px; }
+ul.toc { list-style: none; padding-left: 1.2em; }
+ul.toc summary { cursor: pointer; }
+ul.toc li.leaf { padding-left: 1.1em; }
+</style>
+</head>
+<body>
+<h1>{{ .DocTitle }}</h1>
+<p class="version">{{ .VersionText }}{{ if .DocVersion }} ({{ .DocVersion }}){{ end }}</p>
+{{- if .PDFUrl }}
+<p class="pdf"><a href="{{ .PDFUrl }}">Download as PDF</a></p>
+{{- end }}
+{{ template "entries" .Entries }}
+</body>
+</html>
+{{- define "entries" }}
+<ul class="toc">
+{{- range . }}
+{{- if .Children }}
+<li><details{{ if .Open }} open{{ end }}><summary>{{ template "link" . }}</summary>{{ template "entries" .Children }}</details></li>
+{{- else }}
+<li class="leaf">{{ template "link" . }}</li>
+{{- end }}
+{{- end }}
+</ul>
+{{- end }}
+{{- define "link" }}{{ if .Path }}<a href="{{ .Path }}">{{ .Text }}</a>{{ else }}{{ .Text }}{{ end }}{{ end }}
+`))
+
+// landingPage contains the values rendered into the landing template
+type landingPage struct {
+	DocTitle    string
+	VersionText string
+	DocVersion  string
+	PDFUrl      string
+	CSSFiles    []string
+	Entries     []landingEntry
+}
+
+// landingEntry is a single, possibly nested, link on the landing page
+type landingEntry struct {
+	Text     string
+	Path     string
+	Open     bool
+	Children []landingEntry
+}
+
+// newLandingEntries converts TOC entries into landing page entries
+func newLandingEntries(entries []atlas.TOCEntry, toc *atlas.AtlasTOC, open bool) (landingEntries []landingEntry) {
+	for _, entry := range entries {
+		landing := landingEntry{
+			Text:     entry.Text,
+			Open:     open,
+			Children: newLandingEntries(entry.Children, toc, false),
+		}
+		if entry.LinkAttr.Href != "" {
+			landing.Path = entry.GetContentFilepath(toc, false)
+		}
+		landingEntries = append(landingEntries, landing)
+	}
+	return
+}
+
+// cssFiles returns every stylesheet linked from a page
+func (renderer *Renderer) cssFiles() []string {
+	return append(append([]string{}, CSSFiles...), renderer.LocalCSSFiles()...)
+}
+
+// WriteLandingPage renders a browsable index of the TOC
+func (renderer *Renderer) WriteLandingPage(w io.Writer, toc *atlas.AtlasTOC) error {
+	page := landingPage{
+		DocTitle:    toc.DocTitle,
+		VersionText: toc.Version.VersionText,
+		DocVersion:  toc.Version.DocVersion,
+		PDFUrl:      toc.PDFUrl,
+		CSSFiles:    renderer.cssFiles(),
+		Entries:     newLandingEntries(toc.TOCEntries, toc, true),
+	}
+	return landingTemplate.Execute(w, page)
+}
+
+// WriteCombinedLandingPage renders a landing page for a combined docset with a section for each TOC
+func (renderer *Renderer) WriteCombinedLandingPage(w io.Writer, combined *atlas.AtlasTOC, tocs []*atlas.AtlasTOC) error {
+	page := landingPage{
+		DocTitle:    combined.DocTitle,
+		VersionText: combined.Version.VersionText,
+		DocVersion:  combined.Version.DocVersion,
+		CSSFiles:    renderer.cssFiles(),
+	}
+	for _, toc := range tocs {
+		page.Entries = append(page.Entries, landingEntry{
+			Text:     toc.DocTitle,
+			Open:     true,
+			Children: newLandingEntries(toc.TOCEntries, toc, false),
+		})
+	}
+	return landingTemplate.Execute(w, page)
+}
@@ -0,0 +1,123 @@
+// Package renderer turns downloaded Atlas content into pages for offline viewing
+package renderer
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/vividboarder/docset-sfdc/classifier"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Renderer holds the settings used to render every page
+type Renderer struct {
+	// Sanitize enables removing anything not allowed by the Policy before pages are written
+	Sanitize bool
+	Policy   SanitizePolicy
+	// Report counts everything that was removed while sanitizing
+	Report *SanitizeReport
+	// Highlight enables rendering static syntax highlighting for code blocks
+	Highlight bool
+	// DarkTheme enables the built in prefers-color-scheme: dark theme
+	DarkTheme bool
+	// CustomCSSPath is a user supplied stylesheet to include in every page
+	CustomCSSPath string
+}
+
+// New returns a Renderer that sanitizes with the default policy, highlights code and includes the dark theme
+func New() *Renderer {
+	return &Renderer{
+		Sanitize:  true,
+		Policy:    DefaultSanitizePolicy,
+		Report:    NewSanitizeReport(),
+		Highlight: true,
+		DarkTheme: true,
+	}
+}
+
+// parseContent parses an html fragment into a container node
+func parseContent(content string) (*html.Node, error) {
+	container := &html.Node{
+		Type:     html.ElementNode,
+		Data:     "div",
+		DataAtom: atom.Div,
+	}
+	nodes, err := html.ParseFragment(strings.NewReader(content), container)
+	if err != nil {
+		return nil, err
+	}
+	for _, node := range nodes {
+		container.AppendChild(node)
+	}
+	return container, nil
+}
+
+// renderChildren renders all children of a container node back to html
+func renderChildren(container *html.Node) (string, error) {
+	var buf bytes.Buffer
+	for child := container.FirstChild; child != nil; child = child.NextSibling {
+		if err := html.Render(&buf, child); err != nil {
+			return "", err
+		}
+	}
+	return buf.String(), nil
+}
+
+// RenderContent prepares downloaded content for offline viewing
+// Entries are anchored in the content if the type should parse it
+// sections maps ids in the content to the names of the entries that link to them
+func (renderer *Renderer) RenderContent(page string, content string, entryType classifier.SupportedType, name string, sections map[string]string) (string, error) {
+	if !renderer.Sanitize && !renderer.Highlight && !entryType.ShouldParseContent() {
+		return content, nil
+	}
+
+	container, err := parseContent(content)
+	if err != nil {
+		return "", err
+	}
+
+	if renderer.Sanitize {
+		renderer.Policy.Sanitize(container, page, renderer.Report)
+	}
+
+	if renderer.Highlight {
+		highlightBlocks(container)
+	}
+
+	if entryType.ShouldParseContent() {
+		// Containers are not entries of their own, so only their sections own the entries in them
+		parentName := name
+		if entryType.IsContainer {
+			parentName = ""
+		}
+		addContentAnchors(container, entryType.ContentTypeName, parentName, sections)
+	}
+
+	return renderChildren(container)
+}
+
+// RenderPage returns a complete page for downloaded content, including the page header
+func (renderer *Renderer) RenderPage(page string, content string, entryType classifier.SupportedType, name string, sections map[string]string) (string, error) {
+	body, err := renderer.RenderContent(page, content, entryType, name, sections)
+	if err != nil {
+		return "", err
+	}
+	return renderer.PageHeader() + body, nil
+}
+
+// RewriteLinks replaces the href of every link in a page with the value returned by rewrite
+func RewriteLinks(page string, rewrite func(href string) string) (string, error) {
+	container, err := parseContent(page)
+	if err != nil {
+		return "", err
+	}
+	for _, link := range findElements(container, atom.A) {
+		for i, attr := range link.Attr {
+			if attr.Key == "href" {
+				link.Attr[i].Val = rewrite(attr.Val)
+			}
+		}
+	}
+	return renderChildren(container)
+}
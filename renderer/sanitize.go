@@ -0,0 +1,242 @@
+package renderer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/vividboarder/docset-sfdc/logging"
+	"golang.org/x/net/html"
+)
+
+const sanitizeReportFile = "sanitize-report.txt"
+
+// SanitizePolicy describes what html is allowed to remain in downloaded content
+type SanitizePolicy struct {
+	// Tags that are kept as is. Any other tag is unwrapped, keeping it's children
+	AllowedTags []string `json:"allowed_tags"`
+	// Attributes that are kept on allowed tags
+	AllowedAttributes []string `json:"allowed_attributes"`
+	// Tags that are removed along with all of their children
+	DropTags []string `json:"drop_tags"`
+	// Elements with any of these classes or ids are removed along with their children
+	DropClasses []string `json:"drop_classes"`
+	// Elements linking to any of these hosts are removed along with their children
+	TrackerHosts []string `json:"tracker_hosts"`
+}
+
+// DefaultSanitizePolicy keeps documentation markup and removes anything that needs a network connection
+var DefaultSanitizePolicy = SanitizePolicy{
+	AllowedTags: []string{
+		"a", "abbr", "b", "blockquote", "br", "caption", "cite", "code", "col", "colgroup",
+		"dd", "del", "dfn", "div", "dl", "dt", "em", "figcaption", "figure", "h1", "h2",
+		"h3", "h4", "h5", "h6", "hr", "i", "img", "ins", "kbd", "li", "ol", "p", "pre",
+		"q", "s", "samp", "section", "small", "span", "strong", "sub", "sup", "table",
+		"tbody", "td", "tfoot", "th", "thead", "tr", "tt", "u", "ul", "var",
+	},
+	AllowedAttributes: []string{
+		"abbr", "align", "alt", "border", "cellpadding", "cellspacing", "class", "colspan",
+		"dir", "headers", "height", "href", "id", "lang", "name", "rowspan", "scope",
+		"span", "src", "start", "summary", "title", "type", "valign", "width",
+	},
+	DropTags: []string{
+		"applet", "audio", "base", "button", "embed", "form", "frame", "frameset",
+		"iframe", "input", "link", "meta", "noscript", "object", "script", "select",
+		"style", "textarea", "video",
+	},
+	DropClasses: []string{
+		"feedback", "doc-feedback", "rating", "survey", "social-share",
+	},
+	TrackerHosts: []string{
+		"google-analytics.com", "googletagmanager.com", "doubleclick.net",
+		"omtrdc.net", "demdex.net", "2o7.net", "hotjar.com", "qualtrics.com",
+	},
+}
+
+// alwaysDropTags are removed with their children by every policy, since unwrapping them
+// would show their source as page text
+var alwaysDropTags = []string{"noscript", "script", "style"}
+
+// clone returns a copy of the policy that doesn't share any lists with the original
+func (policy SanitizePolicy) clone() SanitizePolicy {
+	policy.AllowedTags = append([]string(nil), policy.AllowedTags...)
+	policy.AllowedAttributes = append([]string(nil), policy.AllowedAttributes...)
+	policy.DropTags = append([]string(nil), policy.DropTags...)
+	policy.DropClasses = append([]string(nil), policy.DropClasses...)
+	policy.TrackerHosts = append([]string(nil), policy.TrackerHosts...)
+	return policy
+}
+
+// LoadSanitizePolicy reads a SanitizePolicy from a JSON file
+// Any keys missing from the file are kept from DefaultSanitizePolicy
+func LoadSanitizePolicy(path string) (policy SanitizePolicy, err error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return
+	}
+	policy = DefaultSanitizePolicy.clone()
+	err = json.Unmarshal(contents, &policy)
+	return
+}
+
+// SanitizeReport keeps a count of everything that was removed from downloaded content
+type SanitizeReport struct {
+	lock    sync.Mutex
+	removed map[string]int
+	pages   map[string]bool
+}
+
+// NewSanitizeReport returns an empty SanitizeReport
+func NewSanitizeReport() *SanitizeReport {
+	return &SanitizeReport{
+		removed: map[string]int{},
+		pages:   map[string]bool{},
+	}
+}
+
+// Add records that an item was removed from a page
+func (report *SanitizeReport) Add(page string, item string) {
+	report.lock.Lock()
+	defer report.lock.Unlock()
+	report.removed[item]++
+	report.pages[page] = true
+}
+
+// Lines returns a sorted summary of all removed items
+func (report *SanitizeReport) Lines() (lines []string) {
+	report.lock.Lock()
+	defer report.lock.Unlock()
+	for item, count := range report.removed {
+		lines = append(lines, fmt.Sprintf("%d\t%s", count, item))
+	}
+	sort.Strings(lines)
+	return
+}
+
+// Save writes the report to a file in a build dir
+func (report *SanitizeReport) Save(dir string) error {
+	lines := report.Lines()
+	report.lock.Lock()
+	pageCount := len(report.pages)
+	report.lock.Unlock()
+
+	filePath := filepath.Join(dir, sanitizeReportFile)
+	err := os.MkdirAll(filepath.Dir(filePath), 0755)
+	if err != nil {
+		return err
+	}
+
+	contents := fmt.Sprintf("Sanitized %d pages\n", pageCount)
+	for _, line := range lines {
+		contents += line + "\n"
+	}
+	err = ioutil.WriteFile(filePath, []byte(contents), 0644)
+	if err != nil {
+		return err
+	}
+
+	logging.Info("Sanitized %d pages. See %s for details", pageCount, filePath)
+	return nil
+}
+
+// contains returns true if the value is in the list
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// getAttr returns the value of an attribute on an html node
+func getAttr(node *html.Node, key string) string {
+	for _, attr := range node.Attr {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+// shouldDrop returns a reason if a node should be removed along with its children
+func (policy SanitizePolicy) shouldDrop(node *html.Node) string {
+	if contains(alwaysDropTags, node.Data) || contains(policy.DropTags, node.Data) {
+		return fmt.Sprintf("tag <%s>", node.Data)
+	}
+
+	classes := strings.Fields(getAttr(node, "class"))
+	id := getAttr(node, "id")
+	for _, dropClass := range policy.DropClasses {
+		if id == dropClass || contains(classes, dropClass) {
+			return fmt.Sprintf("widget .%s", dropClass)
+		}
+	}
+
+	for _, key := range []string{"src", "href"} {
+		link := strings.ToLower(getAttr(node, key))
+		for _, host := range policy.TrackerHosts {
+			if strings.Contains(link, host) {
+				return fmt.Sprintf("tracker %s", host)
+			}
+		}
+	}
+
+	return ""
+}
+
+// Sanitize removes anything not allowed by the policy from the node's children
+func (policy SanitizePolicy) Sanitize(node *html.Node, page string, report *SanitizeReport) {
+	child := node.FirstChild
+	for child != nil {
+		next := child.NextSibling
+		switch child.Type {
+		case html.CommentNode:
+			node.RemoveChild(child)
+		case html.ElementNode:
+			if reason := policy.shouldDrop(child); reason != "" {
+				report.Add(page, reason)
+				node.RemoveChild(child)
+				break
+			}
+
+			policy.Sanitize(child, page, report)
+
+			if !contains(policy.AllowedTags, child.Data) {
+				// Unwrap the tag, but keep the content
+				report.Add(page, fmt.Sprintf("unwrapped <%s>", child.Data))
+				for grandchild := child.FirstChild; grandchild != nil; grandchild = child.FirstChild {
+					child.RemoveChild(grandchild)
+					node.InsertBefore(grandchild, child)
+				}
+				node.RemoveChild(child)
+				break
+			}
+
+			policy.sanitizeAttrs(child, page, report)
+		}
+		child = next
+	}
+}
+
+// sanitizeAttrs removes attributes that are not allowed or would execute scripts
+func (policy SanitizePolicy) sanitizeAttrs(node *html.Node, page string, report *SanitizeReport) {
+	var attrs []html.Attribute
+	for _, attr := range node.Attr {
+		if !contains(policy.AllowedAttributes, attr.Key) {
+			report.Add(page, fmt.Sprintf("attribute %s", attr.Key))
+			continue
+		}
+		if strings.HasPrefix(strings.ToLower(strings.TrimSpace(attr.Val)), "javascript:") {
+			report.Add(page, fmt.Sprintf("javascript in %s", attr.Key))
+			continue
+		}
+		attrs = append(attrs, attr)
+	}
+	node.Attr = attrs
+}
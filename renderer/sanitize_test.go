@@ -0,0 +1,145 @@
+package renderer
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// sanitizeFragment parses html as the content of a body, sanitizes it and renders it back
+func sanitizeFragment(t *testing.T, policy SanitizePolicy, content string, report *SanitizeReport) string {
+	body := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := html.ParseFragment(strings.NewReader(content), body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, node := range nodes {
+		body.AppendChild(node)
+	}
+
+	policy.Sanitize(body, "page.htm", report)
+
+	var buf bytes.Buffer
+	for child := body.FirstChild; child != nil; child = child.NextSibling {
+		if err := html.Render(&buf, child); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return buf.String()
+}
+
+// TestSanitize checks what the default policy keeps, unwraps and drops
+func TestSanitize(t *testing.T) {
+	cases := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{"allowed", `<p class="x">Text <b>bold</b></p>`, `<p class="x">Text <b>bold</b></p>`},
+		{"unwrap", `<p><font color="red">Red <b>text</b></font></p>`, `<p>Red <b>text</b></p>`},
+		{"unwrap nested", `<center><font>Text</font></center>`, `Text`},
+		{"drop tag", `<p>Before<iframe src="x.htm"></iframe>After</p>`, `<p>BeforeAfter</p>`},
+		{"drop comment", `<p>Before<!-- comment -->After</p>`, `<p>BeforeAfter</p>`},
+		{"drop class", `<div class="content rating"><p>Rate</p></div><p>Kept</p>`, `<p>Kept</p>`},
+		{"drop id", `<div id="feedback"><p>Rate</p></div><p>Kept</p>`, `<p>Kept</p>`},
+		{"tracker src", `<p><img src="https://www.google-analytics.com/collect?v=1"/>Text</p>`, `<p>Text</p>`},
+		{"tracker href", `<p><a href="https://ad.DoubleClick.net/x">Ad</a>Text</p>`, `<p>Text</p>`},
+		{"attribute", `<p onclick="run()" style="color: red">Text</p>`, `<p>Text</p>`},
+		{"javascript href", `<a href="javascript:run()">Run</a>`, `<a>Run</a>`},
+		{"javascript href padded", `<a href="  JavaScript:run()">Run</a>`, `<a>Run</a>`},
+		{"relative href", `<a href="apex_dml.htm#insert">DML</a>`, `<a href="apex_dml.htm#insert">DML</a>`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			actual := sanitizeFragment(t, DefaultSanitizePolicy, c.content, NewSanitizeReport())
+			if actual != c.expected {
+				t.Errorf("Sanitized %s to %s, expected %s", c.content, actual, c.expected)
+			}
+		})
+	}
+}
+
+// TestSanitizeAlwaysDrops checks that scripts and styles are dropped even if a policy allows them
+func TestSanitizeAlwaysDrops(t *testing.T) {
+	policy := SanitizePolicy{
+		AllowedTags:       []string{"p", "script", "style", "noscript"},
+		AllowedAttributes: []string{"class"},
+	}
+	content := `<p>Text</p><script>alert(1)</script><style>p {}</style><noscript>Enable scripts</noscript>`
+
+	actual := sanitizeFragment(t, policy, content, NewSanitizeReport())
+	if expected := `<p>Text</p>`; actual != expected {
+		t.Errorf("Sanitized to %s, expected %s", actual, expected)
+	}
+}
+
+// TestLoadSanitizePolicy checks that keys missing from a policy file are kept from the default
+func TestLoadSanitizePolicy(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sanitize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "policy.json")
+	err = ioutil.WriteFile(path, []byte(`{"drop_classes": ["banner"]}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	policy, err := LoadSanitizePolicy(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(policy.DropClasses) != 1 || policy.DropClasses[0] != "banner" {
+		t.Errorf("Loaded drop classes %v, expected [banner]", policy.DropClasses)
+	}
+	if len(policy.AllowedTags) != len(DefaultSanitizePolicy.AllowedTags) {
+		t.Errorf("Loaded %d allowed tags, expected the %d defaults", len(policy.AllowedTags), len(DefaultSanitizePolicy.AllowedTags))
+	}
+	if len(policy.TrackerHosts) != len(DefaultSanitizePolicy.TrackerHosts) {
+		t.Errorf("Loaded %d tracker hosts, expected the %d defaults", len(policy.TrackerHosts), len(DefaultSanitizePolicy.TrackerHosts))
+	}
+	if contains(DefaultSanitizePolicy.DropClasses, "banner") {
+		t.Error("Loading a policy changed the default policy")
+	}
+}
+
+// TestSanitizeReport checks the counts written to the report file
+func TestSanitizeReport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sanitize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	report := NewSanitizeReport()
+	sanitizeFragment(t, DefaultSanitizePolicy, `<p onclick="x()"><font>A</font><script></script></p>`, report)
+	sanitizeFragment(t, DefaultSanitizePolicy, `<font>B</font><div class="rating"></div>`, report)
+	report.Add("page2.htm", "tag <script>")
+
+	err = report.Save(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	contents, err := ioutil.ReadFile(filepath.Join(dir, sanitizeReportFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "Sanitized 2 pages\n" +
+		"1\tattribute onclick\n" +
+		"1\twidget .rating\n" +
+		"2\ttag <script>\n" +
+		"2\tunwrapped <font>\n"
+	if string(contents) != expected {
+		t.Errorf("Saved report:\n%s\nexpected:\n%s", contents, expected)
+	}
+}
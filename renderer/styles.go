@@ -0,0 +1,114 @@
+package renderer
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// CSSBaseURL is where the Salesforce stylesheets are downloaded from
+var CSSBaseURL = "https://developer.salesforce.com/resource/stylesheets"
+
+// CSSFiles are the Salesforce stylesheets linked from every page
+var CSSFiles = []string{"holygrail.min.css", "docs.min.css", "syntax-highlighter.min.css"}
+
+// Local stylesheets written into the build dir alongside the Salesforce CSS
+const darkThemeFile = "sfdashc-dark.css"
+const customCSSFile = "sfdashc-custom.css"
+
+// darkThemeCSS overrides the Salesforce styles when the viewer prefers a dark color scheme
+const darkThemeCSS = `@media (prefers-color-scheme: dark) {
+	html, body, #main, .content, .body, .section, .topic {
+		background-color: #1e1e1e !important;
+		color: #d4d4d4 !important;
+	}
+	h1, h2, h3, h4, h5, h6, .helpHead1, .helpHead2, .helpHead3, .title {
+		color: #e6e6e6 !important;
+	}
+	a, a:visited, a .keyword {
+		color: #6cb6ff !important;
+	}
+	pre, code, samp, .codeSample, .codeblock, .syntaxhighlighter {
+		background-color: #2d2d2d !important;
+		border-color: #3c3c3c !important;
+		color: #d4d4d4 !important;
+	}
+	table, th, td, .featureTable, .featureTable td, .featureTable th {
+		background-color: #1e1e1e !important;
+		border-color: #3c3c3c !important;
+		color: #d4d4d4 !important;
+	}
+	th, thead td, .featureTable thead th {
+		background-color: #2a2a2a !important;
+	}
+	.note, .important, .tip, .warning, .box {
+		background-color: #262626 !important;
+		border-color: #3c3c3c !important;
+		color: #d4d4d4 !important;
+	}
+	img {
+		filter: brightness(0.9);
+	}
+}
+`
+
+// SaveStylesheets writes the local stylesheets into a build dir
+func (renderer *Renderer) SaveStylesheets(dir string) error {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+
+	if renderer.DarkTheme {
+		err = ioutil.WriteFile(filepath.Join(dir, darkThemeFile), []byte(darkThemeCSS), 0644)
+		if err != nil {
+			return err
+		}
+	}
+
+	if renderer.Highlight {
+		err = ioutil.WriteFile(filepath.Join(dir, highlightFile), []byte(highlightCSS), 0644)
+		if err != nil {
+			return err
+		}
+	}
+
+	if renderer.CustomCSSPath != "" {
+		contents, err := ioutil.ReadFile(renderer.CustomCSSPath)
+		if err != nil {
+			return fmt.Errorf("Could not read custom stylesheet %s: %s", renderer.CustomCSSPath, err.Error())
+		}
+		return ioutil.WriteFile(filepath.Join(dir, customCSSFile), contents, 0644)
+	}
+	return nil
+}
+
+// LocalCSSFiles returns the local stylesheets that should be linked after the Salesforce CSS
+func (renderer *Renderer) LocalCSSFiles() (files []string) {
+	if renderer.DarkTheme {
+		files = append(files, darkThemeFile)
+	}
+	if renderer.Highlight {
+		files = append(files, highlightFile)
+	}
+	// Custom styles come last so they can override everything else
+	if renderer.CustomCSSPath != "" {
+		files = append(files, customCSSFile)
+	}
+	return
+}
+
+// PageHeader returns the html that should be prepended to every downloaded page
+func (renderer *Renderer) PageHeader() string {
+	header := "<meta http-equiv='Content-Type' content='text/html; charset=UTF-8' />" +
+		"<base href=\"../../\"/>\n"
+	for _, cssFile := range CSSFiles {
+		header += fmt.Sprintf("<link rel=\"stylesheet\" type=\"text/css\" href=\"%s\">", cssFile)
+	}
+	for _, cssFile := range renderer.LocalCSSFiles() {
+		header += fmt.Sprintf("<link rel=\"stylesheet\" type=\"text/css\" href=\"%s\">", cssFile)
+	}
+	header += "<style>body { padding: 15px; }</style>"
+	return header
+}